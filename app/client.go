@@ -1,28 +1,35 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"net"
 	"sync/atomic"
 
 	"github.com/md-talim/codecrafters-redis-go/internal/resp"
+	"github.com/md-talim/codecrafters-redis-go/internal/session"
+	"github.com/md-talim/codecrafters-redis-go/internal/stats"
 )
 
 type Client struct {
-	id    int
-	conn  net.Conn
-	redis *Redis
+	id      int
+	conn    net.Conn
+	redis   *Redis
+	session *session.Session
+	stats   *stats.Stats
 }
 
 var clientIDCounter int32
 
-func NewClient(conn net.Conn, redis *Redis) *Client {
+func NewClient(conn net.Conn, redis *Redis, stats *stats.Stats) *Client {
 	id := int(atomic.AddInt32(&clientIDCounter, 1))
 	return &Client{
-		id:    id,
-		conn:  conn,
-		redis: redis,
+		id:      id,
+		conn:    conn,
+		redis:   redis,
+		session: session.New(id, conn),
+		stats:   stats,
 	}
 }
 
@@ -30,30 +37,37 @@ func (c *Client) Handle() {
 	defer c.conn.Close()
 	fmt.Printf("%d: connected\n", c.id)
 
+	c.stats.ClientConnected()
+	defer c.stats.ClientDisconnected()
+
 	parser := resp.NewParser(c.conn)
 
 	for {
-		request, err := parser.Parse()
+		request, err := parser.ParseCommand()
 		if err != nil {
+			if errors.Is(err, resp.ErrProtocol) {
+				c.conn.Write(resp.NewSimpleError("ERR " + err.Error()).Serialize())
+			}
 			if err != io.EOF {
 				fmt.Printf("%d: parse error: %v\n", c.id, err)
 			}
 			break
 		}
 
-		response := c.redis.Evaluate(request)
+		response := c.redis.Evaluate(c.session, request)
 		if response == nil {
 			fmt.Printf("%d: no response\n", c.id)
 			continue
 		}
 
-		_, err = c.conn.Write(response.Serialize())
+		_, err = c.conn.Write(resp.Encode(response, c.session.Protocol))
 		if err != nil {
 			fmt.Printf("%d: write error: %v\n", c.id, err)
 			break
 		}
 	}
 
+	c.redis.Disconnect(c.session)
 	fmt.Printf("%d: disconnected\n", c.id)
 }
 
@@ -11,7 +11,11 @@ func main() {
 	fmt.Println("Starting Redis server...")
 
 	cfg := config.Load()
-	server := NewServer(cfg)
+	server, err := NewServer(cfg)
+	if err != nil {
+		fmt.Printf("Failed to start server: %v\n", err)
+		os.Exit(1)
+	}
 
 	if err := server.Start(); err != nil {
 		fmt.Printf("Server error: %v", err)
@@ -3,8 +3,12 @@ package main
 import (
 	"bufio"
 	"net"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
+
+	"github.com/md-talim/codecrafters-redis-go/internal/config"
 )
 
 func TestServerPing(t *testing.T) {
@@ -36,3 +40,25 @@ func TestServerPing(t *testing.T) {
 		t.Errorf("Expected %q, got %q", expected, response)
 	}
 }
+
+func TestServerLoadFailsOnCorruptRDB(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "dump.rdb"), []byte("REDIS0011\xfe"), 0644); err != nil {
+		t.Fatalf("Failed to write RDB file: %v", err)
+	}
+
+	server, err := NewServer(&config.Config{Dir: dir, DBFilename: "dump.rdb", Databases: 16})
+	if err != nil {
+		t.Fatalf("NewServer failed: %v", err)
+	}
+	if !server.stats.IsLoading() {
+		t.Error("Expected the server to be loading before load runs")
+	}
+
+	if err := server.load(); err == nil {
+		t.Error("Expected an error loading a truncated RDB file")
+	}
+	if server.stats.IsLoading() {
+		t.Error("Expected loading to finish after a failed load")
+	}
+}
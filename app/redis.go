@@ -2,39 +2,56 @@ package main
 
 import (
 	"fmt"
+	"io"
 
 	"github.com/md-talim/codecrafters-redis-go/internal/commands"
 	"github.com/md-talim/codecrafters-redis-go/internal/config"
+	"github.com/md-talim/codecrafters-redis-go/internal/persistence"
+	"github.com/md-talim/codecrafters-redis-go/internal/rdb"
+	"github.com/md-talim/codecrafters-redis-go/internal/replication"
 	"github.com/md-talim/codecrafters-redis-go/internal/resp"
+	"github.com/md-talim/codecrafters-redis-go/internal/session"
+	"github.com/md-talim/codecrafters-redis-go/internal/stats"
 	"github.com/md-talim/codecrafters-redis-go/internal/store"
 )
 
 type Redis struct {
-	storage  store.Storage
-	config   *config.Config
-	registry *commands.Registry
+	databases   *store.Databases
+	config      *config.Config
+	registries  []*commands.Registry
+	replication *replication.Manager
+	stats       *stats.Stats
+
+	// aof is nil unless appendonly is enabled
+	aof *persistence.AOF
 }
 
-func NewRedis(storage store.Storage, config *config.Config) *Redis {
-	registry := commands.NewRegistry(storage, config)
+func NewRedis(databases *store.Databases, config *config.Config, replication *replication.Manager, stats *stats.Stats, saver *persistence.Saver, aof *persistence.AOF) *Redis {
+	registries := make([]*commands.Registry, databases.Len())
+	for db := range registries {
+		registries[db] = commands.NewRegistry(databases, db, config, replication, stats, saver, aof)
+	}
 
 	return &Redis{
-		storage:  storage,
-		config:   config,
-		registry: registry,
+		databases:   databases,
+		config:      config,
+		registries:  registries,
+		replication: replication,
+		stats:       stats,
+		aof:         aof,
 	}
 }
 
-func (r *Redis) Evaluate(command resp.Value) resp.Value {
+func (r *Redis) Evaluate(session *session.Session, command resp.Value) resp.Value {
 	array, ok := command.(*resp.Array)
 	if !ok {
 		return resp.NewSimpleError("ERR command must be an array")
 	}
 
-	return r.evaluateArray(array)
+	return r.evaluateArray(session, array)
 }
 
-func (r *Redis) evaluateArray(array *resp.Array) resp.Value {
+func (r *Redis) evaluateArray(session *session.Session, array *resp.Array) resp.Value {
 	items := array.Items()
 	if len(items) == 0 {
 		return nil
@@ -42,11 +59,75 @@ func (r *Redis) evaluateArray(array *resp.Array) resp.Value {
 
 	commandName := items[0].String()
 
-	command, exists := r.registry.GetCommand(commandName)
+	r.stats.CommandProcessed()
+
+	registry := r.registries[session.DB]
+	command, exists := registry.GetCommand(commandName)
 	if !exists {
 		return resp.NewSimpleError(fmt.Sprintf("ERR unknown command '%s'", commandName))
 	}
 
+	if r.stats.IsLoading() && !registry.IsLoadingCommand(commandName) {
+		return resp.NewSimpleError("LOADING Redis is loading the dataset in memory")
+	}
+
 	args := items[1:]
+	if registry.IsWriteCommand(commandName) {
+		if r.config.IsReplica() && r.config.ReplicaReadOnly && !session.Master {
+			return resp.NewSimpleError("READONLY You can't write against a read only replica.")
+		}
+		return r.replication.Master().Write(session.DB, items, func() resp.Value {
+			result := execute(command, session, args)
+			if _, isError := result.(*resp.SimpleError); !isError && r.aof != nil {
+				r.aof.Append(session.DB, items)
+			}
+			return result
+		})
+	}
+	return execute(command, session, args)
+}
+
+// Replay applies a command read back from the append only file, without
+// logging or propagating it again.
+func (r *Redis) Replay(session *session.Session, command resp.Value) resp.Value {
+	array, ok := command.(*resp.Array)
+	if !ok || len(array.Items()) == 0 {
+		return resp.NewSimpleError("ERR command must be an array")
+	}
+
+	items := array.Items()
+	handler, exists := r.registries[session.DB].GetCommand(items[0].String())
+	if !exists {
+		return resp.NewSimpleError(fmt.Sprintf("ERR unknown command '%s'", items[0].String()))
+	}
+
+	return execute(handler, session, items[1:])
+}
+
+func execute(command commands.CommandHandler, session *session.Session, args []resp.Value) resp.Value {
+	if handler, ok := command.(commands.SessionCommandHandler); ok {
+		return handler.ExecuteWithSession(session, args)
+	}
 	return command.Execute(args)
 }
+
+// Disconnect releases the state held for a closed connection.
+func (r *Redis) Disconnect(session *session.Session) {
+	r.replication.Master().RemoveReplica(session)
+}
+
+// LoadRDB replaces the dataset with the snapshot streamed from a master.
+func (r *Redis) LoadRDB(snapshot io.Reader) error {
+	r.databases.Flush()
+	if _, err := store.LoadRDB(r.databases, rdb.NewReaderFrom(snapshot)); err != nil {
+		return err
+	}
+
+	// The log no longer describes the dataset, so start over from it
+	if r.aof != nil {
+		if err := r.aof.Rewrite(); err != nil {
+			fmt.Printf("Failed to rewrite the append only file: %v\n", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/md-talim/codecrafters-redis-go/internal/config"
+	"github.com/md-talim/codecrafters-redis-go/internal/persistence"
+	"github.com/md-talim/codecrafters-redis-go/internal/replication"
+	"github.com/md-talim/codecrafters-redis-go/internal/resp"
+	"github.com/md-talim/codecrafters-redis-go/internal/session"
+	"github.com/md-talim/codecrafters-redis-go/internal/stats"
+	"github.com/md-talim/codecrafters-redis-go/internal/store"
+)
+
+func newTestRedis(cfg *config.Config) *Redis {
+	databases := store.NewDatabases(cfg.Databases)
+	return NewRedis(databases, cfg, replication.NewManager(cfg), stats.New(), persistence.NewSaver(databases, cfg, func(fn func()) { fn() }), nil)
+}
+
+func command(args ...string) resp.Value {
+	items := make([]resp.Value, len(args))
+	for i, arg := range args {
+		items[i] = resp.NewBulkString(arg)
+	}
+	return resp.NewArray(items)
+}
+
+func TestReadOnlyReplicaRejectsWrites(t *testing.T) {
+	cfg := &config.Config{ReplicaOf: "localhost 6379", ReplicaReadOnly: true, ReplBacklogSize: 1024}
+	redis := newTestRedis(cfg)
+
+	result := redis.Evaluate(session.Detached(), command("SET", "foo", "bar"))
+	expected := "READONLY You can't write against a read only replica."
+	if result.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, result.String())
+	}
+
+	result = redis.Evaluate(session.Detached(), command("GET", "foo"))
+	if _, isBulk := result.(*resp.BulkString); !isBulk {
+		t.Errorf("Expected reads to be allowed, got %q", result.String())
+	}
+
+	master := session.Detached()
+	master.Master = true
+	result = redis.Evaluate(master, command("SET", "foo", "bar"))
+	if result.String() != "OK" {
+		t.Errorf("Expected writes from the master to be applied, got %q", result.String())
+	}
+}
+
+func TestWritableReplicaAcceptsWrites(t *testing.T) {
+	cfg := &config.Config{ReplicaOf: "localhost 6379", ReplicaReadOnly: false, ReplBacklogSize: 1024}
+	redis := newTestRedis(cfg)
+
+	result := redis.Evaluate(session.Detached(), command("SET", "foo", "bar"))
+	if result.String() != "OK" {
+		t.Errorf("Expected 'OK', got %q", result.String())
+	}
+}
+
+func TestSelectIsolatesDatabases(t *testing.T) {
+	redis := newTestRedis(&config.Config{Databases: 16, ReplBacklogSize: 1024})
+	client := session.Detached()
+
+	redis.Evaluate(client, command("SET", "foo", "zero"))
+	if result := redis.Evaluate(client, command("SELECT", "1")); result.String() != "OK" {
+		t.Fatalf("Expected 'OK', got %q", result.String())
+	}
+	if result := redis.Evaluate(client, command("GET", "foo")); string(result.Serialize()) != "$-1\r\n" {
+		t.Errorf("Expected foo to be missing in database 1, got %q", result.String())
+	}
+	redis.Evaluate(client, command("SET", "foo", "one"))
+
+	other := session.Detached()
+	if result := redis.Evaluate(other, command("GET", "foo")); result.String() != "zero" {
+		t.Errorf("Expected other connections to stay on database 0, got %q", result.String())
+	}
+
+	for _, index := range []string{"16", "-1"} {
+		expected := "ERR DB index is out of range"
+		if result := redis.Evaluate(client, command("SELECT", index)); result.String() != expected {
+			t.Errorf("SELECT %s: expected %q, got %q", index, expected, result.String())
+		}
+	}
+}
+
+func TestMoveAndSwapDB(t *testing.T) {
+	redis := newTestRedis(&config.Config{Databases: 16, ReplBacklogSize: 1024})
+	client := session.Detached()
+
+	redis.Evaluate(client, command("SET", "foo", "bar"))
+	tests := []struct {
+		args     []string
+		expected string
+	}{
+		{[]string{"MOVE", "foo", "0"}, "ERR source and destination objects are the same"},
+		{[]string{"MOVE", "foo", "16"}, "ERR DB index is out of range"},
+		{[]string{"MOVE", "missing", "1"}, "0"},
+		{[]string{"MOVE", "foo", "1"}, "1"},
+		{[]string{"DBSIZE"}, "0"},
+		{[]string{"SWAPDB", "0", "1"}, "OK"},
+		{[]string{"DBSIZE"}, "1"},
+		{[]string{"GET", "foo"}, "bar"},
+		{[]string{"SWAPDB", "x", "1"}, "ERR invalid first DB index"},
+		{[]string{"SWAPDB", "0", "99"}, "ERR DB index is out of range"},
+	}
+
+	for _, test := range tests {
+		result := redis.Evaluate(client, command(test.args...))
+		if result.String() != test.expected {
+			t.Errorf("%v: expected %q, got %q", test.args, test.expected, result.String())
+		}
+	}
+}
+
+func TestLoadingRejectsCommands(t *testing.T) {
+	cfg := &config.Config{Databases: 16, ReplBacklogSize: 1024}
+	databases := store.NewDatabases(cfg.Databases)
+	serverStats := stats.New()
+	redis := NewRedis(databases, cfg, replication.NewManager(cfg), serverStats, persistence.NewSaver(databases, cfg, func(fn func()) { fn() }), nil)
+
+	serverStats.StartLoading()
+	expected := "LOADING Redis is loading the dataset in memory"
+	if result := redis.Evaluate(session.Detached(), command("GET", "foo")); result.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, result.String())
+	}
+	if result := redis.Evaluate(session.Detached(), command("INFO", "persistence")); !strings.Contains(result.String(), "loading:1") {
+		t.Errorf("Expected INFO to be served while loading, got %q", result.String())
+	}
+
+	serverStats.FinishLoading()
+	if result := redis.Evaluate(session.Detached(), command("SET", "foo", "bar")); result.String() != "OK" {
+		t.Errorf("Expected 'OK' once loaded, got %q", result.String())
+	}
+}
+
+// roundTrip sends a command through the wire encoding and parser, as a
+// client connection would, and parses the encoded reply.
+func roundTrip(t *testing.T, redis *Redis, args ...string) resp.Value {
+	t.Helper()
+
+	request, err := resp.NewParser(bytes.NewReader(command(args...).Serialize())).ParseCommand()
+	if err != nil {
+		t.Fatalf("Failed to parse request: %v", err)
+	}
+
+	reply := resp.Encode(redis.Evaluate(session.Detached(), request), resp.RESP2)
+	value, err := resp.NewParser(bytes.NewReader(reply)).Parse()
+	if err != nil {
+		t.Fatalf("Failed to parse reply %q: %v", reply, err)
+	}
+	return value
+}
+
+func TestBinaryValuesRoundTrip(t *testing.T) {
+	redis := newTestRedis(&config.Config{Databases: 16, ReplBacklogSize: 1024})
+	values := []string{"null", "", "line\r\nbreak", "\x00nul\x00", "$-1\r\n", "\xff\xfe"}
+
+	for i, value := range values {
+		key := fmt.Sprintf("key:%d", i)
+		if reply := roundTrip(t, redis, "SET", key, value); reply.String() != "OK" {
+			t.Fatalf("SET %q: expected 'OK', got %q", value, reply.String())
+		}
+
+		reply, ok := roundTrip(t, redis, "GET", key).(*resp.BulkString)
+		if !ok || reply.IsNull() || !bytes.Equal(reply.Bytes(), []byte(value)) {
+			t.Errorf("GET: expected %q, got %v", value, reply)
+		}
+	}
+
+	if reply, ok := roundTrip(t, redis, "GET", "missing").(*resp.BulkString); !ok || !reply.IsNull() {
+		t.Errorf("Expected a null bulk string for a missing key, got %v", reply)
+	}
+
+	roundTrip(t, redis, append([]string{"RPUSH", "list"}, values...)...)
+	reply, ok := roundTrip(t, redis, "LRANGE", "list", "0", "-1").(*resp.Array)
+	if !ok || len(reply.Items()) != len(values) {
+		t.Fatalf("Expected %d elements, got %v", len(values), reply)
+	}
+	for i, item := range reply.Items() {
+		bulk, ok := item.(*resp.BulkString)
+		if !ok || bulk.IsNull() || !bytes.Equal(bulk.Bytes(), []byte(values[i])) {
+			t.Errorf("LRANGE element %d: expected %q, got %v", i, values[i], item)
+		}
+	}
+}
@@ -3,22 +3,102 @@ package main
 import (
 	"fmt"
 	"net"
+	"os"
+	"time"
 
 	"github.com/md-talim/codecrafters-redis-go/internal/config"
+	"github.com/md-talim/codecrafters-redis-go/internal/persistence"
+	"github.com/md-talim/codecrafters-redis-go/internal/replication"
+	"github.com/md-talim/codecrafters-redis-go/internal/stats"
 	"github.com/md-talim/codecrafters-redis-go/internal/store"
 )
 
 type Server struct {
-	config *config.Config
-	redis  *Redis
+	config      *config.Config
+	databases   *store.Databases
+	redis       *Redis
+	replication *replication.Manager
+	stats       *stats.Stats
+	saver       *persistence.Saver
+	aof         *persistence.AOF
 }
 
-func NewServer(cfg *config.Config) *Server {
-	storage := store.New(cfg)
+func NewServer(cfg *config.Config) (*Server, error) {
+	serverStats := stats.New()
+	databases := store.NewDatabases(cfg.Databases)
+	manager := replication.NewManager(cfg)
+	saver := persistence.NewSaver(databases, cfg, manager.Master().Exclusive)
+
+	var aof *persistence.AOF
+	if cfg.AppendOnly {
+		aof = persistence.NewAOF(cfg, databases, manager.Master().Exclusive)
+	}
+
+	// Clients are answered with -LOADING until Start has loaded the dataset
+	serverStats.StartLoading()
+
 	return &Server{
-		config: cfg,
-		redis:  NewRedis(storage, cfg),
+		config:      cfg,
+		databases:   databases,
+		redis:       NewRedis(databases, cfg, manager, serverStats, saver, aof),
+		replication: manager,
+		stats:       serverStats,
+		saver:       saver,
+		aof:         aof,
+	}, nil
+}
+
+// load reads the dataset from disk, then starts logging and saving it. The
+// append only file, when enabled and present, is the authoritative copy of
+// the dataset and is loaded instead of the RDB file.
+func (s *Server) load() error {
+	defer s.stats.FinishLoading()
+
+	if s.aof != nil && s.aof.Exists() {
+		if err := loadAOF(s.aof, s.redis); err != nil {
+			return err
+		}
+	} else if err := loadRDB(s.databases, s.config, s.stats); err != nil {
+		return err
+	}
+
+	if s.aof != nil {
+		if err := s.aof.Open(); err != nil {
+			return err
+		}
 	}
+
+	s.saver.ResetChanges()
+	s.saver.Start()
+	return nil
+}
+
+func loadAOF(aof *persistence.AOF, redis *Redis) error {
+	commands, err := aof.Load(redis.Replay)
+	if err != nil {
+		return fmt.Errorf("failed to load append only file: %w", err)
+	}
+	fmt.Printf("Replayed %d commands from the append only file\n", commands)
+	return nil
+}
+
+func loadRDB(databases *store.Databases, cfg *config.Config, serverStats *stats.Stats) error {
+	start := time.Now()
+	result, err := store.Load(databases, cfg, serverStats.LoadingProgress)
+
+	serverStats.SetRDBLoad(stats.RDBLoad{
+		KeysLoaded:  result.KeysLoaded,
+		KeysExpired: result.KeysExpired,
+		Err:         err,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to load RDB file: %w", err)
+	}
+
+	if result.KeysLoaded+result.KeysExpired > 0 {
+		fmt.Printf("Loaded %d keys from the RDB file in %.3f seconds\n", result.KeysLoaded, time.Since(start).Seconds())
+	}
+	return nil
 }
 
 func (s *Server) Start() error {
@@ -32,6 +112,16 @@ func (s *Server) Start() error {
 
 	fmt.Printf("Redis server listening on port %s\n", s.config.Port)
 
+	// The listener is up while loading, so clients get -LOADING instead of
+	// a refused connection. A replica links to its master once loaded.
+	go func() {
+		if err := s.load(); err != nil {
+			fmt.Printf("Fatal error loading the dataset: %v\n", err)
+			os.Exit(1)
+		}
+		s.replication.Start(s.redis)
+	}()
+
 	for {
 		conn, err := listener.Accept()
 		if err != nil {
@@ -39,7 +129,7 @@ func (s *Server) Start() error {
 			continue
 		}
 
-		client := NewClient(conn, s.redis)
+		client := NewClient(conn, s.redis, s.stats)
 		go client.Handle()
 	}
 }
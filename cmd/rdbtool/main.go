@@ -0,0 +1,86 @@
+// Command rdbtool inspects RDB files without starting a server.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/md-talim/codecrafters-redis-go/internal/rdb"
+)
+
+const usage = `Usage: rdbtool <command> [options] <file>
+
+Commands:
+  info      print the aux fields and the key count of each database
+  keys      print the type, size, element count and expiry of each key
+  json      export every key as a line of JSON
+  biggest   print the keys that take the most space in the file
+  check     validate the structure and checksum of the file
+`
+
+func main() {
+	if err := run(os.Args[1:], os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string, out io.Writer) error {
+	if len(args) == 0 {
+		return errors.New(usage)
+	}
+
+	flags := flag.NewFlagSet(args[0], flag.ContinueOnError)
+	count := flags.Int("n", 10, "number of keys to print (biggest)")
+	strict := flags.Bool("strict", false, "reject a zero checksum instead of skipping verification")
+	if err := flags.Parse(args[1:]); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return errors.New(usage)
+	}
+	if *count < 0 {
+		return fmt.Errorf("invalid value %d for -n: must not be negative", *count)
+	}
+	path := flags.Arg(0)
+
+	if args[0] == "check" {
+		return check(path, *strict, out)
+	}
+
+	data, err := load(path, *strict)
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "info":
+		return printInfo(data, out)
+	case "keys":
+		return printKeys(data, out)
+	case "json":
+		return exportJSON(data, out)
+	case "biggest":
+		return printBiggest(data, *count, out)
+	default:
+		return fmt.Errorf("unknown command %q\n\n%s", args[0], usage)
+	}
+}
+
+func load(path string, strict bool) (*rdb.RDBData, error) {
+	reader, err := rdb.NewReader(filepath.Dir(path), filepath.Base(path))
+	if err != nil {
+		return nil, err
+	}
+	if reader == nil {
+		return nil, fmt.Errorf("%s: no such file", path)
+	}
+	defer reader.Close()
+	reader.SkipZeroChecksum(!strict)
+
+	return reader.ReadRDB()
+}
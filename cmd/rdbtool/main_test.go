@@ -0,0 +1,223 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/md-talim/codecrafters-redis-go/internal/rdb"
+)
+
+func writeTestRDB(t *testing.T) string {
+	t.Helper()
+
+	var buffer bytes.Buffer
+	writer := rdb.NewWriter(&buffer)
+	expiresAt := time.UnixMilli(1700000000000)
+	steps := []func() error{
+		writer.WriteHeader,
+		func() error { return writer.WriteAux("redis-ver", "7.2.0") },
+		func() error { return writer.WriteSelectDB(0) },
+		func() error { return writer.WriteString("foo", "bar", &expiresAt) },
+		func() error { return writer.WriteList("list", []string{"a", "b", "c"}, nil) },
+		func() error { return writer.WriteSelectDB(2) },
+		func() error {
+			return writer.WriteSortedSet("zset", []rdb.SortedSetEntry{{Member: "m", Score: 1.5}}, nil)
+		},
+		writer.WriteEOF,
+	}
+	for _, step := range steps {
+		if err := step(); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+	}
+
+	path := filepath.Join(t.TempDir(), "dump.rdb")
+	if err := os.WriteFile(path, buffer.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func runTool(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+	var out bytes.Buffer
+	err := run(args, &out)
+	return out.String(), err
+}
+
+func TestInfo(t *testing.T) {
+	out, err := runTool(t, "info", writeTestRDB(t))
+	if err != nil {
+		t.Fatalf("info failed: %v", err)
+	}
+
+	for _, expected := range []string{"version 11\n", `aux redis-ver = "7.2.0"`, "db0:keys=2,expires=1,", "db2:keys=1,expires=0,"} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("Expected %q in output:\n%s", expected, out)
+		}
+	}
+}
+
+func TestKeys(t *testing.T) {
+	out, err := runTool(t, "keys", writeTestRDB(t))
+	if err != nil {
+		t.Fatalf("keys failed: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("Expected a header and 3 keys, got:\n%s", out)
+	}
+	if fields := strings.Fields(lines[1]); fields[1] != `"foo"` || fields[2] != "string" || fields[5] != "2023-11-14T22:13:20Z" {
+		t.Errorf("Unexpected line for foo: %q", lines[1])
+	}
+	if fields := strings.Fields(lines[2]); fields[1] != `"list"` || fields[2] != "list" || fields[4] != "3" || fields[5] != "-" {
+		t.Errorf("Unexpected line for list: %q", lines[2])
+	}
+}
+
+func TestJSON(t *testing.T) {
+	out, err := runTool(t, "json", writeTestRDB(t))
+	if err != nil {
+		t.Fatalf("json failed: %v", err)
+	}
+
+	var lines []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		var decoded map[string]any
+		if err := json.Unmarshal([]byte(line), &decoded); err != nil {
+			t.Fatalf("Invalid JSON line %q: %v", line, err)
+		}
+		lines = append(lines, decoded)
+	}
+
+	if len(lines) != 3 {
+		t.Fatalf("Expected 3 lines, got %d", len(lines))
+	}
+	if lines[0]["key"] != "foo" || lines[0]["value"] != "bar" || lines[0]["expires_at"] != float64(1700000000000) {
+		t.Errorf("Unexpected line for foo: %v", lines[0])
+	}
+	if _, hasExpiry := lines[1]["expires_at"]; hasExpiry {
+		t.Errorf("Expected no expiry for list: %v", lines[1])
+	}
+	if lines[2]["db"] != float64(2) || lines[2]["type"] != "zset" {
+		t.Errorf("Unexpected line for zset: %v", lines[2])
+	}
+}
+
+func TestJSONSpecialValues(t *testing.T) {
+	var buffer bytes.Buffer
+	writer := rdb.NewWriter(&buffer)
+	steps := []func() error{
+		writer.WriteHeader,
+		func() error { return writer.WriteSelectDB(0) },
+		func() error { return writer.WriteString("bin\xff", "\x00\xfe", nil) },
+		func() error {
+			return writer.WriteSortedSet("zset", []rdb.SortedSetEntry{{Member: "a", Score: math.Inf(1)}, {Member: "b", Score: math.Inf(-1)}}, nil)
+		},
+		writer.WriteEOF,
+	}
+	for _, step := range steps {
+		if err := step(); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+	}
+	path := filepath.Join(t.TempDir(), "dump.rdb")
+	if err := os.WriteFile(path, buffer.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := runTool(t, "json", path)
+	if err != nil {
+		t.Fatalf("json failed: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 lines, got:\n%s", out)
+	}
+	var binary struct {
+		Key, Encoding, Value string
+	}
+	if err := json.Unmarshal([]byte(lines[0]), &binary); err != nil {
+		t.Fatalf("Invalid JSON line %q: %v", lines[0], err)
+	}
+	if binary.Encoding != "base64" || binary.Key != base64.StdEncoding.EncodeToString([]byte("bin\xff")) || binary.Value != base64.StdEncoding.EncodeToString([]byte("\x00\xfe")) {
+		t.Errorf("Expected a base64 encoded line, got %q", lines[0])
+	}
+
+	var zset struct {
+		Encoding string
+		Value    []struct{ Member, Score string }
+	}
+	if err := json.Unmarshal([]byte(lines[1]), &zset); err != nil {
+		t.Fatalf("Invalid JSON line %q: %v", lines[1], err)
+	}
+	if zset.Encoding != "" || len(zset.Value) != 2 || zset.Value[0].Score != "inf" || zset.Value[1].Score != "-inf" {
+		t.Errorf("Expected scores inf and -inf, got %q", lines[1])
+	}
+}
+
+func TestBiggest(t *testing.T) {
+	out, err := runTool(t, "biggest", "-n", "1", writeTestRDB(t))
+	if err != nil {
+		t.Fatalf("biggest failed: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) != 2 || !strings.Contains(lines[1], `"foo"`) {
+		t.Errorf("Expected foo, with its expiry, to be the biggest key, got:\n%s", out)
+	}
+
+	if _, err := runTool(t, "biggest", "-n", "-1", writeTestRDB(t)); err == nil {
+		t.Error("Expected an error for a negative count")
+	}
+}
+
+func TestCheck(t *testing.T) {
+	path := writeTestRDB(t)
+	out, err := runTool(t, "check", path)
+	if err != nil || !strings.Contains(out, "RDB looks OK") {
+		t.Errorf("Expected the file to pass, got %v:\n%s", err, out)
+	}
+
+	data, _ := os.ReadFile(path)
+	data[len(data)-12] ^= 0xff
+	os.WriteFile(path, data, 0o644)
+
+	out, err = runTool(t, "check", path)
+	if err == nil || !strings.Contains(out, "RDB ERROR DETECTED") || !strings.Contains(out, "checksum mismatch") {
+		t.Errorf("Expected a checksum error, got %v:\n%s", err, out)
+	}
+}
+
+func TestCheckCorruptSize(t *testing.T) {
+	data := append([]byte(rdb.RDBHeader), rdb.OpSelectDB, 0, rdb.ValueTypeString, 1, 'k', rdb.Size64Bit)
+	data = append(data, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 'a', rdb.OpEOF)
+	data = append(data, make([]byte, 8)...)
+	path := filepath.Join(t.TempDir(), "dump.rdb")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := runTool(t, "check", path)
+	if err == nil || !strings.Contains(out, "RDB ERROR DETECTED") {
+		t.Errorf("Expected the corrupt size to be reported, got %v:\n%s", err, out)
+	}
+}
+
+func TestUnknownCommand(t *testing.T) {
+	if _, err := runTool(t, "frobnicate", writeTestRDB(t)); err == nil {
+		t.Error("Expected an error for an unknown command")
+	}
+	if _, err := runTool(t); err == nil {
+		t.Error("Expected usage without arguments")
+	}
+}
@@ -0,0 +1,253 @@
+package main
+
+import (
+	"cmp"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"text/tabwriter"
+	"time"
+	"unicode/utf8"
+
+	"github.com/md-talim/codecrafters-redis-go/internal/rdb"
+	"github.com/md-talim/codecrafters-redis-go/internal/resp"
+)
+
+// keyInfo describes a key read from the file.
+type keyInfo struct {
+	db    int
+	key   string
+	value *rdb.RDBValue
+}
+
+// sortedKeys returns every key ordered by database and name.
+func sortedKeys(data *rdb.RDBData) []keyInfo {
+	var keys []keyInfo
+	for db, values := range data.Databases {
+		for key, value := range values {
+			keys = append(keys, keyInfo{db, key, value})
+		}
+	}
+
+	slices.SortFunc(keys, func(a, b keyInfo) int {
+		return cmp.Or(cmp.Compare(a.db, b.db), cmp.Compare(a.key, b.key))
+	})
+	return keys
+}
+
+func typeName(value any) string {
+	switch value.(type) {
+	case string:
+		return "string"
+	case rdb.List:
+		return "list"
+	case rdb.Set:
+		return "set"
+	case rdb.Hash:
+		return "hash"
+	case rdb.SortedSet:
+		return "zset"
+	default:
+		return "unknown"
+	}
+}
+
+// elements returns the number of elements in a collection, or the length of
+// a string.
+func elements(value any) int {
+	switch value := value.(type) {
+	case string:
+		return len(value)
+	case rdb.List:
+		return len(value)
+	case rdb.Set:
+		return len(value)
+	case rdb.Hash:
+		return len(value)
+	case rdb.SortedSet:
+		return len(value)
+	default:
+		return 0
+	}
+}
+
+func formatExpiry(expiresAt *time.Time) string {
+	if expiresAt == nil {
+		return "-"
+	}
+	return expiresAt.UTC().Format(time.RFC3339Nano)
+}
+
+func printInfo(data *rdb.RDBData, out io.Writer) error {
+	fmt.Fprintf(out, "version %d\n", data.Version)
+	for _, field := range data.Aux {
+		fmt.Fprintf(out, "aux %s = %q\n", field.Key, field.Value)
+	}
+
+	dbs := make([]int, 0, len(data.Databases))
+	for db := range data.Databases {
+		dbs = append(dbs, db)
+	}
+	slices.Sort(dbs)
+
+	for _, db := range dbs {
+		expires := 0
+		var size int64
+		for _, value := range data.Databases[db] {
+			if value.ExpiresAt != nil {
+				expires++
+			}
+			size += value.Size
+		}
+		fmt.Fprintf(out, "db%d:keys=%d,expires=%d,bytes=%d\n", db, len(data.Databases[db]), expires, size)
+	}
+	return nil
+}
+
+func printKeys(data *rdb.RDBData, out io.Writer) error {
+	writer := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(writer, "DB\tKEY\tTYPE\tSIZE\tELEMENTS\tEXPIRES")
+	for _, key := range sortedKeys(data) {
+		fmt.Fprintf(writer, "%d\t%q\t%s\t%d\t%d\t%s\n", key.db, key.key, typeName(key.value.Value), key.value.Size, elements(key.value.Value), formatExpiry(key.value.ExpiresAt))
+	}
+	return writer.Flush()
+}
+
+// printBiggest prints the count keys with the largest size in the file.
+func printBiggest(data *rdb.RDBData, count int, out io.Writer) error {
+	keys := sortedKeys(data)
+	slices.SortStableFunc(keys, func(a, b keyInfo) int {
+		return cmp.Compare(b.value.Size, a.value.Size)
+	})
+	keys = keys[:min(count, len(keys))]
+
+	writer := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(writer, "RANK\tDB\tKEY\tTYPE\tSIZE\tELEMENTS")
+	for i, key := range keys {
+		fmt.Fprintf(writer, "%d\t%d\t%q\t%s\t%d\t%d\n", i+1, key.db, key.key, typeName(key.value.Value), key.value.Size, elements(key.value.Value))
+	}
+	return writer.Flush()
+}
+
+// jsonKey is a line of the JSON export. Scores are strings formatted as
+// Redis replies with them, so inf, -inf and nan survive. JSON strings hold
+// text only, so when the key or any string in the value is not valid UTF-8
+// every string of the line is base64 encoded and Encoding says so.
+type jsonKey struct {
+	DB        int    `json:"db"`
+	Key       string `json:"key"`
+	Type      string `json:"type"`
+	Size      int64  `json:"size"`
+	ExpiresAt *int64 `json:"expires_at,omitempty"`
+	Encoding  string `json:"encoding,omitempty"`
+	Value     any    `json:"value"`
+}
+
+type jsonEntry struct {
+	Member string `json:"member"`
+	Score  string `json:"score"`
+}
+
+func exportJSON(data *rdb.RDBData, out io.Writer) error {
+	encoder := json.NewEncoder(out)
+	for _, key := range sortedKeys(data) {
+		encode := func(s string) string { return s }
+		encoding := ""
+		if !validUTF8(key.key) || !validUTF8(allStrings(key.value.Value)...) {
+			encode = func(s string) string { return base64.StdEncoding.EncodeToString([]byte(s)) }
+			encoding = "base64"
+		}
+
+		line := jsonKey{
+			DB:       key.db,
+			Key:      encode(key.key),
+			Type:     typeName(key.value.Value),
+			Size:     key.value.Size,
+			Encoding: encoding,
+			Value:    jsonValue(key.value.Value, encode),
+		}
+		if key.value.ExpiresAt != nil {
+			milliseconds := key.value.ExpiresAt.UnixMilli()
+			line.ExpiresAt = &milliseconds
+		}
+
+		if err := encoder.Encode(line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// jsonValue converts a value to its JSON form, passing each string through
+// encode.
+func jsonValue(value any, encode func(string) string) any {
+	switch value := value.(type) {
+	case string:
+		return encode(value)
+	case rdb.List:
+		return encodeAll(value, encode)
+	case rdb.Set:
+		return encodeAll(value, encode)
+	case rdb.Hash:
+		converted := make(map[string]string, len(value))
+		for field, fieldValue := range value {
+			converted[encode(field)] = encode(fieldValue)
+		}
+		return converted
+	case rdb.SortedSet:
+		converted := make([]jsonEntry, len(value))
+		for i, entry := range value {
+			converted[i] = jsonEntry{encode(entry.Member), resp.NewDouble(entry.Score).String()}
+		}
+		return converted
+	default:
+		return value
+	}
+}
+
+func encodeAll(values []string, encode func(string) string) []string {
+	converted := make([]string, len(values))
+	for i, value := range values {
+		converted[i] = encode(value)
+	}
+	return converted
+}
+
+// allStrings returns every string held by a value: the string itself, the
+// elements of a list or set, the fields and values of a hash or the members
+// of a sorted set.
+func allStrings(value any) []string {
+	switch value := value.(type) {
+	case string:
+		return []string{value}
+	case rdb.List:
+		return value
+	case rdb.Set:
+		return value
+	case rdb.Hash:
+		all := make([]string, 0, 2*len(value))
+		for field, fieldValue := range value {
+			all = append(all, field, fieldValue)
+		}
+		return all
+	case rdb.SortedSet:
+		all := make([]string, len(value))
+		for i, entry := range value {
+			all[i] = entry.Member
+		}
+		return all
+	default:
+		return nil
+	}
+}
+
+func validUTF8(values ...string) bool {
+	for _, value := range values {
+		if !utf8.ValidString(value) {
+			return false
+		}
+	}
+	return true
+}
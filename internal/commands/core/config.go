@@ -38,15 +38,12 @@ func (c *ConfigCommand) handleGet(args []resp.Value) resp.Value {
 	param := strings.ToLower(args[0].String())
 	value, exists := c.config.GetParameter(param)
 	if !exists {
-		return resp.NewArray([]resp.Value{})
+		return resp.NewMap([]resp.MapEntry{})
 	}
 
-	result := []resp.Value{
-		resp.NewBulkString(param),
-		resp.NewBulkString(value),
-	}
-
-	return resp.NewArray(result)
+	return resp.NewMap([]resp.MapEntry{
+		{Key: resp.NewBulkString(param), Value: resp.NewBulkString(value)},
+	})
 }
 
 func (c *ConfigCommand) Name() string {
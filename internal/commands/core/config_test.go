@@ -22,22 +22,22 @@ func TestConfigGetDir(t *testing.T) {
 
 	result := cmd.Execute(args)
 
-	array, isArray := result.(*resp.Array)
-	if !isArray {
-		t.Errorf("Expected Array")
+	reply, isMap := result.(*resp.Map)
+	if !isMap {
+		t.Fatalf("Expected Map")
 	}
 
-	items := array.Items()
-	if len(items) != 2 {
-		t.Errorf("Expected 2 elements, got %d", len(items))
+	entries := reply.Entries()
+	if len(entries) != 1 {
+		t.Fatalf("Expected 1 entry, got %d", len(entries))
 	}
 
-	if items[0].String() != "dir" {
-		t.Errorf("Expected 'dir', got %q", items[0].String())
+	if entries[0].Key.String() != "dir" {
+		t.Errorf("Expected 'dir', got %q", entries[0].Key.String())
 	}
 
-	if items[1].String() != "/tmp/redis-files" {
-		t.Errorf("Expected '/tmp/redis-files', got %q", items[1].String())
+	if entries[0].Value.String() != "/tmp/redis-files" {
+		t.Errorf("Expected '/tmp/redis-files', got %q", entries[0].Value.String())
 	}
 }
 
@@ -56,22 +56,22 @@ func TestConfigGetDBFilename(t *testing.T) {
 
 	result := cmd.Execute(args)
 
-	array, isArray := result.(*resp.Array)
-	if !isArray {
-		t.Errorf("Expected Array")
+	reply, isMap := result.(*resp.Map)
+	if !isMap {
+		t.Fatalf("Expected Map")
 	}
 
-	items := array.Items()
-	if len(items) != 2 {
-		t.Errorf("Expected 2 elements, got %d", len(items))
+	entries := reply.Entries()
+	if len(entries) != 1 {
+		t.Fatalf("Expected 1 entry, got %d", len(entries))
 	}
 
-	if items[0].String() != "dbfilename" {
-		t.Errorf("Expected 'dbfilename', got %q", items[0].String())
+	if entries[0].Key.String() != "dbfilename" {
+		t.Errorf("Expected 'dbfilename', got %q", entries[0].Key.String())
 	}
 
-	if items[1].String() != "dump.rdb" {
-		t.Errorf("Expected 'dump.rdb', got %q", items[1].String())
+	if entries[0].Value.String() != "dump.rdb" {
+		t.Errorf("Expected 'dump.rdb', got %q", entries[0].Value.String())
 	}
 }
 
@@ -90,12 +90,12 @@ func TestConfigGetUnknownParameter(t *testing.T) {
 
 	result := cmd.Execute(args)
 
-	array, isArray := result.(*resp.Array)
-	if !isArray {
-		t.Errorf("Expected Array")
+	reply, isMap := result.(*resp.Map)
+	if !isMap {
+		t.Fatalf("Expected Map")
 	}
 
-	if len(array.Items()) != 0 {
-		t.Errorf("Expected empty array, got %d elements", len(array.Items()))
+	if len(reply.Entries()) != 0 {
+		t.Errorf("Expected empty map, got %d entries", len(reply.Entries()))
 	}
 }
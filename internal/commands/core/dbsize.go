@@ -0,0 +1,29 @@
+package core
+
+import (
+	"strconv"
+
+	"github.com/md-talim/codecrafters-redis-go/internal/resp"
+	"github.com/md-talim/codecrafters-redis-go/internal/store"
+)
+
+type DBSizeCommand struct {
+	storage store.Storage
+}
+
+func NewDBSizeCommand(storage store.Storage) *DBSizeCommand {
+	return &DBSizeCommand{storage}
+}
+
+func (c *DBSizeCommand) Execute(args []resp.Value) resp.Value {
+	if len(args) != 0 {
+		return WrongNumberOfArgumentsError("dbsize")
+	}
+
+	keys, _ := c.storage.Count()
+	return resp.NewInteger(strconv.Itoa(keys))
+}
+
+func (c *DBSizeCommand) Name() string {
+	return "DBSIZE"
+}
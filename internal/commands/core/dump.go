@@ -0,0 +1,116 @@
+package core
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/md-talim/codecrafters-redis-go/internal/rdb"
+	"github.com/md-talim/codecrafters-redis-go/internal/resp"
+	"github.com/md-talim/codecrafters-redis-go/internal/store"
+)
+
+type DumpCommand struct {
+	storage store.Storage
+}
+
+func NewDumpCommand(storage store.Storage) *DumpCommand {
+	return &DumpCommand{storage}
+}
+
+func (c *DumpCommand) Execute(args []resp.Value) resp.Value {
+	if len(args) != 1 {
+		return WrongNumberOfArgumentsError("dump")
+	}
+
+	value, exists := c.storage.Get(args[0].String())
+	if !exists {
+		return resp.NewNullBulkString()
+	}
+
+	payload, err := store.Dump(value)
+	if err != nil {
+		return resp.NewSimpleError("ERR " + err.Error())
+	}
+
+	return resp.NewBulkBytes(payload)
+}
+
+func (c *DumpCommand) Name() string {
+	return "DUMP"
+}
+
+type RestoreCommand struct {
+	storage store.Storage
+}
+
+func NewRestoreCommand(storage store.Storage) *RestoreCommand {
+	return &RestoreCommand{storage}
+}
+
+// Execute handles RESTORE key ttl payload [REPLACE] [ABSTTL]. A ttl of zero
+// restores the key without an expiry; with ABSTTL it is a Unix time in
+// milliseconds rather than a duration.
+func (c *RestoreCommand) Execute(args []resp.Value) resp.Value {
+	if len(args) < 3 {
+		return WrongNumberOfArgumentsError("restore")
+	}
+
+	key := args[0].String()
+	ttl, err := strconv.ParseInt(args[1].String(), 10, 64)
+	if err != nil {
+		return ValueNotIntegerError()
+	}
+	if ttl < 0 {
+		return resp.NewSimpleError("ERR Invalid TTL value, must be >= 0")
+	}
+
+	var replace, absolute bool
+	for _, arg := range args[3:] {
+		switch strings.ToUpper(arg.String()) {
+		case "REPLACE":
+			replace = true
+		case "ABSTTL":
+			absolute = true
+		default:
+			return SyntaxError()
+		}
+	}
+
+	if _, exists := c.storage.Get(key); exists && !replace {
+		return resp.NewSimpleError("BUSYKEY Target key name already exists.")
+	}
+
+	value, err := store.Restore(resp.Bytes(args[2]))
+	if errors.Is(err, rdb.ErrBadDumpPayload) {
+		return resp.NewSimpleError("ERR DUMP payload version or checksum are wrong")
+	}
+	if err != nil {
+		return resp.NewSimpleError("ERR Bad data format")
+	}
+
+	expiry := time.Duration(ttl) * time.Millisecond
+	if absolute && ttl > 0 {
+		expiry = time.Until(time.UnixMilli(ttl))
+	}
+
+	switch {
+	case ttl == 0:
+		err = c.storage.Set(key, value)
+	case expiry <= 0:
+		// Already expired, so only the key being replaced goes away
+		err = c.storage.Delete(key)
+	default:
+		err = c.storage.SetWithExpiry(key, value, expiry)
+	}
+	if err != nil {
+		return resp.NewSimpleError("ERR failed to restore key")
+	}
+
+	return resp.NewSimpleString("OK")
+}
+
+func (c *RestoreCommand) Name() string {
+	return "RESTORE"
+}
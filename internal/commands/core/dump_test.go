@@ -0,0 +1,114 @@
+package core
+
+import (
+	"encoding/binary"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/md-talim/codecrafters-redis-go/internal/rdb"
+	"github.com/md-talim/codecrafters-redis-go/internal/resp"
+	"github.com/md-talim/codecrafters-redis-go/internal/store"
+)
+
+func bulkStrings(args ...string) []resp.Value {
+	items := make([]resp.Value, len(args))
+	for i, arg := range args {
+		items[i] = resp.NewBulkString(arg)
+	}
+	return items
+}
+
+func TestDumpAndRestore(t *testing.T) {
+	source := store.NewInMemory()
+	list := store.NewList()
+	list.Append(bulkStrings("a", "b"))
+	source.Set("list", list)
+	source.Set("foo", []byte("bar"))
+
+	target := store.NewInMemory()
+	restore := NewRestoreCommand(target)
+
+	for _, key := range []string{"foo", "list"} {
+		payload := NewDumpCommand(source).Execute(bulkStrings(key)).String()
+		if result := restore.Execute(bulkStrings(key, "0", payload)); result.String() != "OK" {
+			t.Fatalf("RESTORE %s: expected 'OK', got %q", key, result.String())
+		}
+	}
+
+	if value, _ := target.Get("foo"); !reflect.DeepEqual(value, []byte("bar")) {
+		t.Errorf("Expected foo=bar, got %v", value)
+	}
+	restored, _ := target.Get("list")
+	if list, ok := restored.(*store.List); !ok || list.Size() != 2 {
+		t.Errorf("Expected a list of 2 elements, got %v", restored)
+	}
+
+	if result := NewDumpCommand(source).Execute(bulkStrings("missing")); string(result.Serialize()) != "$-1\r\n" {
+		t.Errorf("Expected a null reply for a missing key, got %q", result.Serialize())
+	}
+}
+
+func TestRestoreOptions(t *testing.T) {
+	source := store.NewInMemory()
+	source.Set("foo", []byte("bar"))
+	payload := NewDumpCommand(source).Execute(bulkStrings("foo")).String()
+
+	target := store.NewInMemory()
+	restore := NewRestoreCommand(target)
+	target.Set("foo", []byte("old"))
+
+	past := strconv.FormatInt(time.Now().Add(-time.Hour).UnixMilli(), 10)
+	tests := []struct {
+		args     []string
+		expected string
+	}{
+		{[]string{"foo", "0", payload}, "BUSYKEY Target key name already exists."},
+		{[]string{"foo", "-1", payload, "REPLACE"}, "ERR Invalid TTL value, must be >= 0"},
+		{[]string{"foo", "0", payload, "NOPE"}, "ERR syntax error"},
+		{[]string{"bar", "0", payload[:len(payload)-1] + "x"}, "ERR DUMP payload version or checksum are wrong"},
+		{[]string{"foo", "60000", payload, "REPLACE"}, "OK"},
+		{[]string{"baz", past, payload, "ABSTTL"}, "OK"},
+	}
+
+	for _, test := range tests {
+		result := restore.Execute(bulkStrings(test.args...))
+		if result.String() != test.expected {
+			t.Errorf("RESTORE %v: expected %q, got %q", test.args[:2], test.expected, result.String())
+		}
+	}
+
+	if keys, expires := target.Count(); keys != 1 || expires != 1 {
+		t.Errorf("Expected foo to be replaced with an expiry and baz to be skipped, got %d keys, %d expires", keys, expires)
+	}
+}
+
+// dumpPayload wraps a serialized value in a DUMP footer with a valid
+// version and checksum.
+func dumpPayload(value string) string {
+	payload := binary.LittleEndian.AppendUint16([]byte(value), rdb.MaxRDBVersion)
+	return string(binary.LittleEndian.AppendUint64(payload, rdb.CRC64(0, payload)))
+}
+
+func TestRestoreHostileSizes(t *testing.T) {
+	restore := NewRestoreCommand(store.NewInMemory())
+
+	// Sizes far beyond the payload fail without allocating memory for them
+	values := map[string]string{
+		"set of 2^32 members":      "\x02\x80\xff\x00\x30\x30",
+		"string of 2^40 bytes":     "\x00\x81\x00\x00\x00\xff\xff\xff\xff\xff",
+		"lzf of 2^40 bytes":        "\x00\xc3\x02\x81\x00\x00\x00\xff\xff\xff\xff\xff\x00a",
+		"lzf past compressed data": "\x00\xc3\x81\x00\x00\x00\xff\xff\xff\xff\xff\x02\x00a",
+		"intset of -1 members":     "\x0b\x08\x02\x00\x00\x00\xff\xff\xff\xff",
+		"intset of 2^31 members":   "\x0b\x0a\x02\x00\x00\x00\x00\x00\x00\x80\x01\x00",
+		"listpack string of 2^32":  "\x14\x0c\x00\x00\x00\x00\x01\x00\xf0\xff\xff\xff\xff\xff",
+	}
+
+	for name, value := range values {
+		result := restore.Execute(bulkStrings("k", "0", dumpPayload(value)))
+		if result.String() != "ERR Bad data format" {
+			t.Errorf("%s: expected 'ERR Bad data format', got %q", name, result.String())
+		}
+	}
+}
@@ -37,3 +37,7 @@ func ValueNotIntegerError() resp.Value {
 func WrongTypeOperationError() resp.Value {
 	return resp.NewSimpleError("WRONGTYPE Operation against a key holding the wrong kind of value")
 }
+
+func DBIndexOutOfRangeError() resp.Value {
+	return resp.NewSimpleError("ERR DB index is out of range")
+}
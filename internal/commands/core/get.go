@@ -1,8 +1,6 @@
 package core
 
 import (
-	"fmt"
-
 	"github.com/md-talim/codecrafters-redis-go/internal/resp"
 	"github.com/md-talim/codecrafters-redis-go/internal/store"
 )
@@ -26,7 +24,12 @@ func (g *GetCommand) Execute(args []resp.Value) resp.Value {
 		return resp.NewNullBulkString()
 	}
 
-	return resp.NewBulkString(fmt.Sprintf("%v", value))
+	str, ok := value.([]byte)
+	if !ok {
+		return WrongTypeOperationError()
+	}
+
+	return resp.NewBulkBytes(str)
 }
 
 func (g *GetCommand) Name() string {
@@ -9,7 +9,7 @@ import (
 
 func TestGetCommandExists(t *testing.T) {
 	memoryStorage := store.NewInMemory()
-	memoryStorage.Set("foo", "bar")
+	memoryStorage.Set("foo", []byte("bar"))
 
 	getCmd := NewGetCommand(memoryStorage)
 	getCmdArgs := []resp.Value{
@@ -40,7 +40,7 @@ func TestGetCommandNotExists(t *testing.T) {
 		t.Errorf("Expected BulkString (null)")
 	}
 
-	if result.String() != "null" {
+	if !result.(*resp.BulkString).IsNull() {
 		t.Errorf("Expected null bulk string, got %q", result.Serialize())
 	}
 }
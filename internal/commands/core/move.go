@@ -0,0 +1,48 @@
+package core
+
+import (
+	"strconv"
+
+	"github.com/md-talim/codecrafters-redis-go/internal/resp"
+	"github.com/md-talim/codecrafters-redis-go/internal/session"
+	"github.com/md-talim/codecrafters-redis-go/internal/store"
+)
+
+type MoveCommand struct {
+	databases *store.Databases
+}
+
+func NewMoveCommand(databases *store.Databases) *MoveCommand {
+	return &MoveCommand{databases}
+}
+
+func (c *MoveCommand) Execute(args []resp.Value) resp.Value {
+	return c.ExecuteWithSession(session.Detached(), args)
+}
+
+// ExecuteWithSession moves a key from the selected database to another one.
+func (c *MoveCommand) ExecuteWithSession(s *session.Session, args []resp.Value) resp.Value {
+	if len(args) != 2 {
+		return WrongNumberOfArgumentsError("move")
+	}
+
+	dst, err := strconv.Atoi(args[1].String())
+	if err != nil {
+		return ValueNotIntegerError()
+	}
+	if !c.databases.Valid(dst) {
+		return DBIndexOutOfRangeError()
+	}
+	if dst == s.DB {
+		return resp.NewSimpleError("ERR source and destination objects are the same")
+	}
+
+	if !c.databases.Move(args[0].String(), s.DB, dst) {
+		return resp.NewInteger("0")
+	}
+	return resp.NewInteger("1")
+}
+
+func (c *MoveCommand) Name() string {
+	return "MOVE"
+}
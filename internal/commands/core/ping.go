@@ -9,8 +9,11 @@ func NewPingCommand() *PingCommand {
 }
 
 func (p *PingCommand) Execute(args []resp.Value) resp.Value {
-	if len(args) == 0 {
+	switch len(args) {
+	case 0:
 		return resp.NewSimpleString("PONG")
+	case 1:
+		return resp.NewBulkString(args[0].String())
 	}
 
 	return WrongNumberOfArgumentsError("ping")
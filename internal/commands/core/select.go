@@ -0,0 +1,43 @@
+package core
+
+import (
+	"strconv"
+
+	"github.com/md-talim/codecrafters-redis-go/internal/resp"
+	"github.com/md-talim/codecrafters-redis-go/internal/session"
+	"github.com/md-talim/codecrafters-redis-go/internal/store"
+)
+
+type SelectCommand struct {
+	databases *store.Databases
+}
+
+func NewSelectCommand(databases *store.Databases) *SelectCommand {
+	return &SelectCommand{databases}
+}
+
+func (c *SelectCommand) Execute(args []resp.Value) resp.Value {
+	return c.ExecuteWithSession(session.Detached(), args)
+}
+
+// ExecuteWithSession switches the database the connection operates on.
+func (c *SelectCommand) ExecuteWithSession(s *session.Session, args []resp.Value) resp.Value {
+	if len(args) != 1 {
+		return WrongNumberOfArgumentsError("select")
+	}
+
+	index, err := strconv.Atoi(args[0].String())
+	if err != nil {
+		return ValueNotIntegerError()
+	}
+	if !c.databases.Valid(index) {
+		return DBIndexOutOfRangeError()
+	}
+
+	s.DB = index
+	return resp.NewSimpleString("OK")
+}
+
+func (c *SelectCommand) Name() string {
+	return "SELECT"
+}
@@ -23,7 +23,7 @@ func (s *SetCommand) Execute(args []resp.Value) resp.Value {
 	}
 
 	key := args[0].String()
-	value := args[1].String()
+	value := resp.Bytes(args[1])
 
 	var expiry time.Duration
 	var hasExpiry bool
@@ -44,13 +44,29 @@ func (s *SetCommand) Execute(args []resp.Value) resp.Value {
 			expiry = time.Duration(milliseconds) * time.Millisecond
 			hasExpiry = true
 			i++
+		case "PXAT":
+			if i+1 >= len(args) {
+				return SyntaxError()
+			}
+
+			timestamp, err := strconv.ParseInt(args[i+1].String(), 10, 64)
+			if err != nil || timestamp <= 0 {
+				return InvalidExpireTimeError("set")
+			}
+
+			expiry = time.Until(time.UnixMilli(timestamp))
+			hasExpiry = true
+			i++
 		default:
 			return SyntaxError()
 		}
 	}
 
 	var err error
-	if hasExpiry {
+	if hasExpiry && expiry <= 0 {
+		// An absolute expiry in the past, as replayed from a log
+		err = s.storage.Delete(key)
+	} else if hasExpiry {
 		err = s.storage.SetWithExpiry(key, value, expiry)
 	} else {
 		err = s.storage.Set(key, value)
@@ -1,6 +1,8 @@
 package core
 
 import (
+	"reflect"
+	"strconv"
 	"testing"
 	"time"
 
@@ -29,7 +31,7 @@ func TestSetCommand(t *testing.T) {
 	if !exists {
 		t.Errorf("Expected key 'foo' to exist")
 	}
-	if value != "bar" {
+	if !reflect.DeepEqual(value, []byte("bar")) {
 		t.Errorf("Expected 'bar', got %q", value)
 	}
 }
@@ -74,7 +76,7 @@ func TestSetCommandWithPX(t *testing.T) {
 	if !exists {
 		t.Error("Expected key 'foo' to exist")
 	}
-	if value != "bar" {
+	if !reflect.DeepEqual(value, []byte("bar")) {
 		t.Errorf("Expected 'bar', got %q", value)
 	}
 
@@ -87,3 +89,23 @@ func TestSetCommandWithPX(t *testing.T) {
 		t.Error("Expected key 'foo' to be expired")
 	}
 }
+
+func TestSetCommandWithPXAT(t *testing.T) {
+	memoryStorage := store.NewInMemory()
+	cmd := NewSetCommand(memoryStorage)
+
+	future := strconv.FormatInt(time.Now().Add(time.Hour).UnixMilli(), 10)
+	cmd.Execute([]resp.Value{resp.NewBulkString("foo"), resp.NewBulkString("bar"), resp.NewBulkString("PXAT"), resp.NewBulkString(future)})
+	if _, expires := memoryStorage.Count(); expires != 1 {
+		t.Errorf("Expected foo to have an expiry")
+	}
+
+	past := strconv.FormatInt(time.Now().Add(-time.Hour).UnixMilli(), 10)
+	result := cmd.Execute([]resp.Value{resp.NewBulkString("foo"), resp.NewBulkString("bar"), resp.NewBulkString("PXAT"), resp.NewBulkString(past)})
+	if result.String() != "OK" {
+		t.Errorf("Expected 'OK', got %q", result.String())
+	}
+	if _, exists := memoryStorage.Get("foo"); exists {
+		t.Errorf("Expected a past PXAT to remove foo")
+	}
+}
@@ -0,0 +1,43 @@
+package core
+
+import (
+	"strconv"
+
+	"github.com/md-talim/codecrafters-redis-go/internal/resp"
+	"github.com/md-talim/codecrafters-redis-go/internal/store"
+)
+
+type SwapDBCommand struct {
+	databases *store.Databases
+}
+
+func NewSwapDBCommand(databases *store.Databases) *SwapDBCommand {
+	return &SwapDBCommand{databases}
+}
+
+// Execute exchanges the contents of two databases. Connections that selected
+// either one see the other's data from then on.
+func (c *SwapDBCommand) Execute(args []resp.Value) resp.Value {
+	if len(args) != 2 {
+		return WrongNumberOfArgumentsError("swapdb")
+	}
+
+	first, err := strconv.Atoi(args[0].String())
+	if err != nil {
+		return resp.NewSimpleError("ERR invalid first DB index")
+	}
+	second, err := strconv.Atoi(args[1].String())
+	if err != nil {
+		return resp.NewSimpleError("ERR invalid second DB index")
+	}
+	if !c.databases.Valid(first) || !c.databases.Valid(second) {
+		return DBIndexOutOfRangeError()
+	}
+
+	c.databases.Swap(first, second)
+	return resp.NewSimpleString("OK")
+}
+
+func (c *SwapDBCommand) Name() string {
+	return "SWAPDB"
+}
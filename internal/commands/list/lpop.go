@@ -47,6 +47,7 @@ func (c *LPopCommand) Execute(args []resp.Value) resp.Value {
 
 	if count == 1 {
 		poppedElement := list.Pop()
+		c.storage.MarkDirty(1)
 		return poppedElement
 	}
 
@@ -56,6 +57,7 @@ func (c *LPopCommand) Execute(args []resp.Value) resp.Value {
 		poppedElements = append(poppedElements, list.Pop())
 		i++
 	}
+	c.storage.MarkDirty(len(poppedElements))
 
 	return resp.NewArray(poppedElements)
 }
@@ -6,8 +6,13 @@ import (
 
 	"github.com/md-talim/codecrafters-redis-go/internal/commands/core"
 	"github.com/md-talim/codecrafters-redis-go/internal/commands/list"
+	"github.com/md-talim/codecrafters-redis-go/internal/commands/server"
 	"github.com/md-talim/codecrafters-redis-go/internal/config"
+	"github.com/md-talim/codecrafters-redis-go/internal/persistence"
+	"github.com/md-talim/codecrafters-redis-go/internal/replication"
 	"github.com/md-talim/codecrafters-redis-go/internal/resp"
+	"github.com/md-talim/codecrafters-redis-go/internal/session"
+	"github.com/md-talim/codecrafters-redis-go/internal/stats"
 	"github.com/md-talim/codecrafters-redis-go/internal/store"
 )
 
@@ -15,18 +20,62 @@ type CommandHandler interface {
 	Execute([]resp.Value) resp.Value
 }
 
+// SessionCommandHandler is implemented by commands that need the state of the
+// connection issuing them. When present it is preferred over Execute.
+type SessionCommandHandler interface {
+	CommandHandler
+	ExecuteWithSession(*session.Session, []resp.Value) resp.Value
+}
+
+// writeCommands lists the commands that modify the dataset. They are
+// propagated to replicas after executing successfully.
+var writeCommands = map[string]bool{
+	"SET":   true,
+	"RPUSH": true,
+	"LPUSH": true,
+	"LPOP":  true,
+
+	"MOVE":    true,
+	"SWAPDB":  true,
+	"RESTORE": true,
+}
+
+// loadingCommands lists the commands served while the dataset is loaded at
+// startup. Every other command is answered with -LOADING.
+var loadingCommands = map[string]bool{
+	"INFO":     true,
+	"HELLO":    true,
+	"CONFIG":   true,
+	"ECHO":     true,
+	"SELECT":   true,
+	"LASTSAVE": true,
+	"REPLCONF": true,
+}
+
+// Registry holds the commands bound to one database. The server keeps a
+// registry per database and dispatches to the one the connection selected.
 type Registry struct {
-	storage  store.Storage
-	config   *config.Config
-	commands map[string]CommandHandler
-	mu       sync.RWMutex
+	databases   *store.Databases
+	storage     store.Storage
+	config      *config.Config
+	replication *replication.Manager
+	stats       *stats.Stats
+	saver       *persistence.Saver
+	aof         *persistence.AOF
+	commands    map[string]CommandHandler
+	mu          sync.RWMutex
 }
 
-func NewRegistry(storage store.Storage, config *config.Config) *Registry {
+func NewRegistry(databases *store.Databases, db int, config *config.Config, replication *replication.Manager, stats *stats.Stats, saver *persistence.Saver, aof *persistence.AOF) *Registry {
 	registry := &Registry{
-		storage:  storage,
-		config:   config,
-		commands: make(map[string]CommandHandler),
+		databases:   databases,
+		storage:     databases.DB(db),
+		config:      config,
+		replication: replication,
+		stats:       stats,
+		saver:       saver,
+		aof:         aof,
+		commands:    make(map[string]CommandHandler),
 	}
 
 	registry.registerCommands()
@@ -36,17 +85,37 @@ func NewRegistry(storage store.Storage, config *config.Config) *Registry {
 
 func (r *Registry) registerCommands() {
 	r.commands = map[string]CommandHandler{
-		"CONFIG": core.NewConfigCommand(r.config),
-		"ECHO":   core.NewEchoCommand(),
-		"GET":    core.NewGetCommand(r.storage),
-		"KEYS":   core.NewKeysCommand(r.storage),
-		"PING":   core.NewPingCommand(),
-		"SET":    core.NewSetCommand(r.storage),
-		"RPUSH":  list.NewRPushCommand(r.storage),
-		"LPUSH":  list.NewLPushCommand(r.storage),
-		"LPOP":   list.NewLPopCommand(r.storage),
-		"LRANGE": list.NewLRangeCommand(r.storage),
-		"LLEN":   list.NewLLenCommand(r.storage),
+		"CONFIG":  core.NewConfigCommand(r.config),
+		"ECHO":    core.NewEchoCommand(),
+		"GET":     core.NewGetCommand(r.storage),
+		"KEYS":    core.NewKeysCommand(r.storage),
+		"PING":    core.NewPingCommand(),
+		"SET":     core.NewSetCommand(r.storage),
+		"SELECT":  core.NewSelectCommand(r.databases),
+		"DBSIZE":  core.NewDBSizeCommand(r.storage),
+		"MOVE":    core.NewMoveCommand(r.databases),
+		"SWAPDB":  core.NewSwapDBCommand(r.databases),
+		"DUMP":    core.NewDumpCommand(r.storage),
+		"RESTORE": core.NewRestoreCommand(r.storage),
+		"RPUSH":   list.NewRPushCommand(r.storage),
+		"LPUSH":   list.NewLPushCommand(r.storage),
+		"LPOP":    list.NewLPopCommand(r.storage),
+		"LRANGE":  list.NewLRangeCommand(r.storage),
+		"LLEN":    list.NewLLenCommand(r.storage),
+
+		"HELLO":     server.NewHelloCommand(r.replication),
+		"INFO":      server.NewInfoCommand(r.databases, r.config, r.replication, r.stats, r.saver, r.aof),
+		"REPLCONF":  server.NewReplConfCommand(r.replication.Master()),
+		"PSYNC":     server.NewPSyncCommand(r.databases, r.replication.Master()),
+		"REPLICAOF": server.NewReplicaOfCommand(r.replication),
+		"SLAVEOF":   server.NewReplicaOfCommand(r.replication),
+		"WAIT":      server.NewWaitCommand(r.replication.Master()),
+
+		"SAVE":     server.NewSaveCommand(r.saver),
+		"BGSAVE":   server.NewBgSaveCommand(r.saver),
+		"LASTSAVE": server.NewLastSaveCommand(r.saver),
+
+		"BGREWRITEAOF": server.NewBgRewriteAOFCommand(r.aof),
 	}
 }
 
@@ -57,3 +126,13 @@ func (r *Registry) GetCommand(name string) (CommandHandler, bool) {
 	cmd, exists := r.commands[strings.ToUpper(name)]
 	return cmd, exists
 }
+
+func (r *Registry) IsWriteCommand(name string) bool {
+	return writeCommands[strings.ToUpper(name)]
+}
+
+// IsLoadingCommand reports whether the command is served while the dataset
+// is being loaded.
+func (r *Registry) IsLoadingCommand(name string) bool {
+	return loadingCommands[strings.ToUpper(name)]
+}
@@ -0,0 +1,41 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/md-talim/codecrafters-redis-go/internal/commands/core"
+	"github.com/md-talim/codecrafters-redis-go/internal/persistence"
+	"github.com/md-talim/codecrafters-redis-go/internal/resp"
+)
+
+type BgRewriteAOFCommand struct {
+	aof *persistence.AOF
+}
+
+func NewBgRewriteAOFCommand(aof *persistence.AOF) *BgRewriteAOFCommand {
+	return &BgRewriteAOFCommand{aof}
+}
+
+func (c *BgRewriteAOFCommand) Execute(args []resp.Value) resp.Value {
+	if len(args) != 0 {
+		return core.WrongNumberOfArgumentsError("bgrewriteaof")
+	}
+
+	if c.aof == nil {
+		return resp.NewSimpleError("ERR Background append only file rewriting requires appendonly yes")
+	}
+
+	if err := c.aof.Rewrite(); err != nil {
+		if errors.Is(err, persistence.ErrRewriteInProgress) {
+			return resp.NewSimpleError("ERR Background append only file rewriting already in progress")
+		}
+		return resp.NewSimpleError(fmt.Sprintf("ERR %v", err))
+	}
+
+	return resp.NewSimpleString("Background append only file rewriting started")
+}
+
+func (c *BgRewriteAOFCommand) Name() string {
+	return "BGREWRITEAOF"
+}
@@ -0,0 +1,97 @@
+package server
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/md-talim/codecrafters-redis-go/internal/replication"
+	"github.com/md-talim/codecrafters-redis-go/internal/resp"
+	"github.com/md-talim/codecrafters-redis-go/internal/session"
+)
+
+// defaultUser is the only user known to the server. It has no password, so
+// any password authenticates it.
+const defaultUser = "default"
+
+type HelloCommand struct {
+	replication *replication.Manager
+}
+
+func NewHelloCommand(replication *replication.Manager) *HelloCommand {
+	return &HelloCommand{replication}
+}
+
+func (c *HelloCommand) Execute(args []resp.Value) resp.Value {
+	return c.ExecuteWithSession(session.Detached(), args)
+}
+
+// ExecuteWithSession switches the connection to the requested protocol
+// version and replies with a description of the server.
+func (c *HelloCommand) ExecuteWithSession(s *session.Session, args []resp.Value) resp.Value {
+	protocol := s.Protocol
+	if len(args) > 0 {
+		version, err := strconv.Atoi(args[0].String())
+		if err != nil {
+			return resp.NewSimpleError("ERR Protocol version is not an integer or out of range")
+		}
+		if version < resp.RESP2 || version > resp.RESP3 {
+			return resp.NewSimpleError("NOPROTO unsupported protocol version")
+		}
+		protocol = version
+	}
+
+	name, setName := "", false
+	for i := 1; i < len(args); i++ {
+		option := strings.ToUpper(args[i].String())
+		switch {
+		case option == "AUTH" && i+2 < len(args):
+			if args[i+1].String() != defaultUser {
+				return resp.NewSimpleError("WRONGPASS invalid username-password pair or user is disabled.")
+			}
+			i += 2
+		case option == "SETNAME" && i+1 < len(args):
+			name, setName = args[i+1].String(), true
+			if !validClientName(name) {
+				return resp.NewSimpleError("ERR Client names cannot contain spaces, newlines or special characters.")
+			}
+			i++
+		default:
+			return resp.NewSimpleError("ERR Syntax error in HELLO option '" + args[i].String() + "'")
+		}
+	}
+
+	s.Protocol = protocol
+	if setName {
+		s.Name = name
+	}
+
+	role := "master"
+	if c.replication.Replica() != nil {
+		role = "replica"
+	}
+
+	return resp.NewMap([]resp.MapEntry{
+		{Key: resp.NewBulkString("server"), Value: resp.NewBulkString("redis")},
+		{Key: resp.NewBulkString("version"), Value: resp.NewBulkString(redisVersion)},
+		{Key: resp.NewBulkString("proto"), Value: resp.NewInteger(strconv.Itoa(protocol))},
+		{Key: resp.NewBulkString("id"), Value: resp.NewInteger(strconv.Itoa(s.ID))},
+		{Key: resp.NewBulkString("mode"), Value: resp.NewBulkString("standalone")},
+		{Key: resp.NewBulkString("role"), Value: resp.NewBulkString(role)},
+		{Key: resp.NewBulkString("modules"), Value: resp.NewArray([]resp.Value{})},
+	})
+}
+
+// validClientName reports whether a client name is made only of printable
+// characters other than space.
+func validClientName(name string) bool {
+	for i := 0; i < len(name); i++ {
+		if name[i] < '!' || name[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *HelloCommand) Name() string {
+	return "HELLO"
+}
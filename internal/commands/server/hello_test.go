@@ -0,0 +1,72 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/md-talim/codecrafters-redis-go/internal/config"
+	"github.com/md-talim/codecrafters-redis-go/internal/replication"
+	"github.com/md-talim/codecrafters-redis-go/internal/resp"
+	"github.com/md-talim/codecrafters-redis-go/internal/session"
+)
+
+func helloArgs(args ...string) []resp.Value {
+	values := make([]resp.Value, len(args))
+	for i, arg := range args {
+		values[i] = resp.NewBulkString(arg)
+	}
+	return values
+}
+
+func TestHelloSwitchesProtocol(t *testing.T) {
+	cmd := NewHelloCommand(replication.NewManager(&config.Config{ReplBacklogSize: 1024}))
+	s := session.Detached()
+
+	result := cmd.ExecuteWithSession(s, helloArgs("3", "AUTH", "default", "secret", "SETNAME", "worker"))
+	reply, isMap := result.(*resp.Map)
+	if !isMap {
+		t.Fatalf("Expected Map, got %q", result.String())
+	}
+
+	fields := make(map[string]string)
+	for _, entry := range reply.Entries() {
+		fields[entry.Key.String()] = entry.Value.String()
+	}
+	if fields["server"] != "redis" || fields["proto"] != "3" || fields["role"] != "master" {
+		t.Errorf("Unexpected HELLO reply %v", fields)
+	}
+
+	if s.Protocol != resp.RESP3 || s.Name != "worker" {
+		t.Errorf("Expected protocol 3 and name 'worker', got %d and %q", s.Protocol, s.Name)
+	}
+
+	cmd.ExecuteWithSession(s, helloArgs("2"))
+	if s.Protocol != resp.RESP2 {
+		t.Errorf("Expected protocol 2, got %d", s.Protocol)
+	}
+}
+
+func TestHelloErrors(t *testing.T) {
+	cmd := NewHelloCommand(replication.NewManager(&config.Config{ReplBacklogSize: 1024}))
+
+	tests := []struct {
+		args     []string
+		expected string
+	}{
+		{[]string{"4"}, "NOPROTO unsupported protocol version"},
+		{[]string{"three"}, "ERR Protocol version is not an integer or out of range"},
+		{[]string{"3", "AUTH", "alice", "secret"}, "WRONGPASS invalid username-password pair or user is disabled."},
+		{[]string{"3", "SETNAME", "two words"}, "ERR Client names cannot contain spaces, newlines or special characters."},
+		{[]string{"3", "AUTH", "default"}, "ERR Syntax error in HELLO option 'AUTH'"},
+	}
+
+	for _, test := range tests {
+		s := session.Detached()
+		result := cmd.ExecuteWithSession(s, helloArgs(test.args...))
+		if _, isError := result.(*resp.SimpleError); !isError || result.String() != test.expected {
+			t.Errorf("HELLO %v: expected %q, got %q", test.args, test.expected, result.String())
+		}
+		if s.Protocol != resp.RESP2 {
+			t.Errorf("HELLO %v: expected the protocol to be unchanged, got %d", test.args, s.Protocol)
+		}
+	}
+}
@@ -0,0 +1,284 @@
+package server
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/md-talim/codecrafters-redis-go/internal/config"
+	"github.com/md-talim/codecrafters-redis-go/internal/persistence"
+	"github.com/md-talim/codecrafters-redis-go/internal/replication"
+	"github.com/md-talim/codecrafters-redis-go/internal/resp"
+	"github.com/md-talim/codecrafters-redis-go/internal/stats"
+	"github.com/md-talim/codecrafters-redis-go/internal/store"
+)
+
+const redisVersion = "7.2.0"
+
+// defaultInfoSections are the sections returned by INFO without arguments.
+var defaultInfoSections = []string{
+	"server",
+	"clients",
+	"memory",
+	"persistence",
+	"stats",
+	"replication",
+	"keyspace",
+}
+
+type InfoCommand struct {
+	databases   *store.Databases
+	config      *config.Config
+	replication *replication.Manager
+	stats       *stats.Stats
+	saver       *persistence.Saver
+	aof         *persistence.AOF
+}
+
+func NewInfoCommand(databases *store.Databases, config *config.Config, replication *replication.Manager, stats *stats.Stats, saver *persistence.Saver, aof *persistence.AOF) *InfoCommand {
+	return &InfoCommand{databases, config, replication, stats, saver, aof}
+}
+
+func (c *InfoCommand) Execute(args []resp.Value) resp.Value {
+	sections := defaultInfoSections
+	if len(args) > 0 {
+		sections = []string{}
+		for _, arg := range args {
+			section := strings.ToLower(arg.String())
+			switch section {
+			case "default", "all", "everything":
+				sections = append(sections, defaultInfoSections...)
+			default:
+				sections = append(sections, section)
+			}
+		}
+	}
+
+	var builder strings.Builder
+	written := make(map[string]bool)
+	for _, section := range sections {
+		if written[section] {
+			continue
+		}
+		written[section] = true
+
+		fields := c.section(section)
+		if fields == nil {
+			continue
+		}
+
+		if builder.Len() > 0 {
+			builder.WriteString(resp.CRLF)
+		}
+		builder.WriteString("# " + strings.ToUpper(section[:1]) + section[1:] + resp.CRLF)
+		for _, field := range fields {
+			builder.WriteString(field + resp.CRLF)
+		}
+	}
+
+	return resp.NewBulkString(builder.String())
+}
+
+// section returns the field:value lines of a section, or nil if unknown.
+func (c *InfoCommand) section(name string) []string {
+	switch name {
+	case "server":
+		return c.serverSection()
+	case "clients":
+		return c.clientsSection()
+	case "memory":
+		return c.memorySection()
+	case "persistence":
+		return c.persistenceSection()
+	case "stats":
+		return c.statsSection()
+	case "replication":
+		return c.replicationSection()
+	case "keyspace":
+		return c.keyspaceSection()
+	default:
+		return nil
+	}
+}
+
+func (c *InfoCommand) serverSection() []string {
+	uptime := c.stats.Uptime()
+	return []string{
+		"redis_version:" + redisVersion,
+		"redis_mode:standalone",
+		"os:" + runtime.GOOS + " " + runtime.GOARCH,
+		"arch_bits:64",
+		"go_version:" + runtime.Version(),
+		fmt.Sprintf("process_id:%d", os.Getpid()),
+		"tcp_port:" + c.config.Port,
+		fmt.Sprintf("uptime_in_seconds:%d", int64(uptime.Seconds())),
+		fmt.Sprintf("uptime_in_days:%d", int64(uptime.Hours()/24)),
+	}
+}
+
+func (c *InfoCommand) clientsSection() []string {
+	return []string{
+		fmt.Sprintf("connected_clients:%d", c.stats.ConnectedClients()),
+	}
+}
+
+func (c *InfoCommand) memorySection() []string {
+	var memory runtime.MemStats
+	runtime.ReadMemStats(&memory)
+
+	return []string{
+		fmt.Sprintf("used_memory:%d", memory.HeapAlloc),
+		"used_memory_human:" + humanBytes(memory.HeapAlloc),
+		fmt.Sprintf("used_memory_rss:%d", memory.Sys),
+		"used_memory_rss_human:" + humanBytes(memory.Sys),
+	}
+}
+
+func (c *InfoCommand) persistenceSection() []string {
+	load := c.stats.RDBLoad()
+	status := "ok"
+	if load.Err != nil {
+		status = "err"
+	}
+
+	bgsaveStatus := "ok"
+	if c.saver.LastBackgroundSaveStatus() != nil {
+		bgsaveStatus = "err"
+	}
+
+	aofWriteStatus, aofRewriteStatus := "ok", "ok"
+	if c.aof != nil && c.aof.LastWriteStatus() != nil {
+		aofWriteStatus = "err"
+	}
+	if c.aof != nil && c.aof.LastRewriteStatus() != nil {
+		aofRewriteStatus = "err"
+	}
+
+	lines := []string{
+		fmt.Sprintf("loading:%d", boolToInt(c.stats.IsLoading())),
+		fmt.Sprintf("rdb_changes_since_last_save:%d", c.saver.ChangesSinceLastSave()),
+		fmt.Sprintf("rdb_bgsave_in_progress:%d", boolToInt(c.saver.BackgroundSaveInProgress())),
+		fmt.Sprintf("rdb_last_save_time:%d", c.saver.LastSave().Unix()),
+		"rdb_last_bgsave_status:" + bgsaveStatus,
+		fmt.Sprintf("rdb_last_load_keys_loaded:%d", load.KeysLoaded),
+		fmt.Sprintf("rdb_last_load_keys_expired:%d", load.KeysExpired),
+		"rdb_last_load_status:" + status,
+		fmt.Sprintf("aof_enabled:%d", boolToInt(c.aof != nil)),
+		fmt.Sprintf("aof_rewrite_in_progress:%d", boolToInt(c.aof != nil && c.aof.RewriteInProgress())),
+		"aof_last_bgrewrite_status:" + aofRewriteStatus,
+		"aof_last_write_status:" + aofWriteStatus,
+	}
+
+	if c.aof != nil {
+		current, base := c.aof.Sizes()
+		lines = append(lines,
+			fmt.Sprintf("aof_current_size:%d", current),
+			fmt.Sprintf("aof_base_size:%d", base),
+		)
+	}
+
+	if c.stats.IsLoading() {
+		loading := c.stats.Loading()
+		lines = append(lines,
+			fmt.Sprintf("loading_start_time:%d", loading.StartTime.Unix()),
+			fmt.Sprintf("loading_total_bytes:%d", loading.TotalBytes),
+			fmt.Sprintf("loading_loaded_bytes:%d", loading.LoadedBytes),
+			fmt.Sprintf("loading_loaded_perc:%.2f", loading.Percent()),
+			fmt.Sprintf("loading_eta_seconds:%d", int64(loading.ETA(time.Now()).Seconds())),
+		)
+	}
+	return lines
+}
+
+func (c *InfoCommand) statsSection() []string {
+	return []string{
+		fmt.Sprintf("total_connections_received:%d", c.stats.TotalConnections()),
+		fmt.Sprintf("total_commands_processed:%d", c.stats.TotalCommands()),
+	}
+}
+
+func (c *InfoCommand) replicationSection() []string {
+	master := c.replication.Master()
+	replica := c.replication.Replica()
+
+	var fields []string
+	if replica == nil {
+		fields = append(fields, "role:master")
+	} else {
+		linkStatus := "down"
+		if replica.LinkUp() {
+			linkStatus = "up"
+		}
+		fields = append(fields,
+			"role:slave",
+			"master_host:"+replica.MasterHost(),
+			"master_port:"+replica.MasterPort(),
+			"master_link_status:"+linkStatus,
+			fmt.Sprintf("slave_repl_offset:%d", replica.Offset()),
+			fmt.Sprintf("slave_read_only:%d", boolToInt(c.config.ReplicaReadOnly)),
+		)
+	}
+
+	replicas := master.Replicas()
+	fields = append(fields, fmt.Sprintf("connected_slaves:%d", len(replicas)))
+	for i, info := range replicas {
+		// The lag is the number of seconds since the replica last sent
+		// REPLCONF ACK
+		lag := int(time.Since(info.LastAck).Seconds())
+		fields = append(fields, fmt.Sprintf("slave%d:ip=%s,port=%s,state=online,offset=%d,lag=%d",
+			i, info.IP, info.Port, info.AckOffset, lag))
+	}
+
+	replID, offset := master.ReplID(), master.Offset()
+	if replica != nil {
+		replID, offset = replica.ReplID(), replica.Offset()
+	}
+
+	backlog := master.Backlog()
+	fields = append(fields,
+		"master_replid:"+replID,
+		fmt.Sprintf("master_repl_offset:%d", offset),
+		fmt.Sprintf("repl_backlog_size:%d", backlog.Size),
+		fmt.Sprintf("repl_backlog_first_byte_offset:%d", backlog.FirstByteOffset),
+		fmt.Sprintf("repl_backlog_histlen:%d", backlog.Length),
+	)
+
+	return fields
+}
+
+func (c *InfoCommand) keyspaceSection() []string {
+	fields := []string{}
+	for i := range c.databases.Len() {
+		keys, expires := c.databases.DB(i).Count()
+		if keys == 0 {
+			continue
+		}
+		fields = append(fields, fmt.Sprintf("db%d:keys=%d,expires=%d,avg_ttl=0", i, keys, expires))
+	}
+
+	return fields
+}
+
+func (c *InfoCommand) Name() string {
+	return "INFO"
+}
+
+func humanBytes(bytes uint64) string {
+	units := []string{"B", "K", "M", "G"}
+	value := float64(bytes)
+	unit := 0
+	for value >= 1024 && unit < len(units)-1 {
+		value /= 1024
+		unit++
+	}
+	return fmt.Sprintf("%.2f%s", value, units[unit])
+}
+
+func boolToInt(value bool) int {
+	if value {
+		return 1
+	}
+	return 0
+}
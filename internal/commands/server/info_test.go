@@ -0,0 +1,110 @@
+package server
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/md-talim/codecrafters-redis-go/internal/config"
+	"github.com/md-talim/codecrafters-redis-go/internal/persistence"
+	"github.com/md-talim/codecrafters-redis-go/internal/replication"
+	"github.com/md-talim/codecrafters-redis-go/internal/resp"
+	"github.com/md-talim/codecrafters-redis-go/internal/stats"
+	"github.com/md-talim/codecrafters-redis-go/internal/store"
+)
+
+func newInfoCommand(cfg *config.Config) (*InfoCommand, *store.Databases) {
+	databases := store.NewDatabases(cfg.Databases)
+	return NewInfoCommand(databases, cfg, replication.NewManager(cfg), stats.New(), persistence.NewSaver(databases, cfg, func(fn func()) { fn() }), nil), databases
+}
+
+func TestInfoReplicationMaster(t *testing.T) {
+	cmd, _ := newInfoCommand(&config.Config{Port: "6379", ReplBacklogSize: 1024})
+
+	result := cmd.Execute([]resp.Value{resp.NewBulkString("replication")})
+
+	if _, isBulk := result.(*resp.BulkString); !isBulk {
+		t.Fatalf("Expected BulkString")
+	}
+
+	info := result.String()
+	if !strings.HasPrefix(info, "# Replication\r\n") {
+		t.Errorf("Expected replication header, got %q", info)
+	}
+
+	for _, field := range []string{"role:master", "connected_slaves:0", "master_repl_offset:0", "master_replid:"} {
+		if !strings.Contains(info, field) {
+			t.Errorf("Expected %q in %q", field, info)
+		}
+	}
+
+	if strings.Contains(info, "# Server") {
+		t.Errorf("Expected only the replication section, got %q", info)
+	}
+}
+
+func TestInfoReplicationReplica(t *testing.T) {
+	cfg := &config.Config{Port: "6380", ReplicaOf: "localhost 1", ReplBacklogSize: 1024}
+	databases := store.NewDatabases(1)
+	manager := replication.NewManager(cfg)
+	manager.Start(nil)
+	defer manager.PromoteToMaster()
+	cmd := NewInfoCommand(databases, cfg, manager, stats.New(), persistence.NewSaver(databases, cfg, func(fn func()) { fn() }), nil)
+
+	info := cmd.Execute([]resp.Value{resp.NewBulkString("replication")}).String()
+
+	for _, field := range []string{"role:slave", "master_host:localhost", "master_port:1", "master_link_status:down"} {
+		if !strings.Contains(info, field) {
+			t.Errorf("Expected %q in %q", field, info)
+		}
+	}
+}
+
+func TestInfoKeyspace(t *testing.T) {
+	cmd, databases := newInfoCommand(&config.Config{Port: "6379", Databases: 16, ReplBacklogSize: 1024})
+	databases.DB(0).Set("foo", []byte("bar"))
+	databases.DB(0).SetWithExpiry("temp", []byte("value"), time.Hour)
+	databases.DB(3).Set("other", []byte("value"))
+
+	info := cmd.Execute([]resp.Value{resp.NewBulkString("keyspace")}).String()
+
+	expected := "# Keyspace\r\ndb0:keys=2,expires=1,avg_ttl=0\r\ndb3:keys=1,expires=0,avg_ttl=0\r\n"
+	if info != expected {
+		t.Errorf("Expected %q, got %q", expected, info)
+	}
+}
+
+func TestInfoDefaultSections(t *testing.T) {
+	cmd, _ := newInfoCommand(&config.Config{Port: "6379", ReplBacklogSize: 1024})
+
+	info := cmd.Execute([]resp.Value{}).String()
+
+	for _, header := range []string{"# Server", "# Clients", "# Memory", "# Persistence", "# Stats", "# Replication", "# Keyspace"} {
+		if !strings.Contains(info, header) {
+			t.Errorf("Expected %q in INFO output", header)
+		}
+	}
+}
+
+func TestInfoPersistenceLoading(t *testing.T) {
+	cfg := &config.Config{Databases: 16, ReplBacklogSize: 1024}
+	databases := store.NewDatabases(cfg.Databases)
+	serverStats := stats.New()
+	cmd := NewInfoCommand(databases, cfg, replication.NewManager(cfg), serverStats, persistence.NewSaver(databases, cfg, func(fn func()) { fn() }), nil)
+
+	serverStats.StartLoading()
+	serverStats.LoadingProgress(250, 1000)
+
+	info := cmd.Execute([]resp.Value{resp.NewBulkString("persistence")}).String()
+	for _, field := range []string{"loading:1", "loading_total_bytes:1000", "loading_loaded_bytes:250", "loading_loaded_perc:25.00", "loading_eta_seconds:"} {
+		if !strings.Contains(info, field) {
+			t.Errorf("Expected %q in %q", field, info)
+		}
+	}
+
+	serverStats.FinishLoading()
+	info = cmd.Execute([]resp.Value{resp.NewBulkString("persistence")}).String()
+	if !strings.Contains(info, "loading:0") || strings.Contains(info, "loading_total_bytes") {
+		t.Errorf("Expected no loading progress once loaded, got %q", info)
+	}
+}
@@ -0,0 +1,66 @@
+package server
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+
+	"github.com/md-talim/codecrafters-redis-go/internal/commands/core"
+	"github.com/md-talim/codecrafters-redis-go/internal/replication"
+	"github.com/md-talim/codecrafters-redis-go/internal/resp"
+	"github.com/md-talim/codecrafters-redis-go/internal/session"
+	"github.com/md-talim/codecrafters-redis-go/internal/store"
+)
+
+type PSyncCommand struct {
+	databases *store.Databases
+	master    *replication.Master
+}
+
+func NewPSyncCommand(databases *store.Databases, master *replication.Master) *PSyncCommand {
+	return &PSyncCommand{databases, master}
+}
+
+func (c *PSyncCommand) Execute(args []resp.Value) resp.Value {
+	return c.ExecuteWithSession(session.Detached(), args)
+}
+
+// ExecuteWithSession resumes the replica from the backlog when possible and
+// otherwise sends a full snapshot. Either way the reply is written straight
+// to the connection, which becomes a replica, so it returns nil.
+func (c *PSyncCommand) ExecuteWithSession(s *session.Session, args []resp.Value) resp.Value {
+	if len(args) != 2 {
+		return core.WrongNumberOfArgumentsError("psync")
+	}
+
+	if s.IsDetached() {
+		return resp.NewSimpleError("ERR PSYNC requires a replica connection")
+	}
+
+	replID := args[0].String()
+	if offset, err := strconv.ParseInt(args[1].String(), 10, 64); err == nil && replID != "?" {
+		resumed, err := c.master.PartialResync(s, replID, offset)
+		if err != nil {
+			fmt.Printf("%d: partial resync failed: %v\n", s.ID, err)
+		}
+		if resumed {
+			return nil
+		}
+	}
+
+	snapshot := func() ([]byte, error) {
+		var buffer bytes.Buffer
+		err := store.WriteRDB(c.databases, &buffer)
+		return buffer.Bytes(), err
+	}
+
+	if err := c.master.FullResync(s, snapshot); err != nil {
+		fmt.Printf("%d: full resync failed: %v\n", s.ID, err)
+	}
+
+	return nil
+}
+
+func (c *PSyncCommand) Name() string {
+	return "PSYNC"
+}
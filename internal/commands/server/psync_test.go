@@ -0,0 +1,91 @@
+package server
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/md-talim/codecrafters-redis-go/internal/rdb"
+	"github.com/md-talim/codecrafters-redis-go/internal/replication"
+	"github.com/md-talim/codecrafters-redis-go/internal/resp"
+	"github.com/md-talim/codecrafters-redis-go/internal/session"
+	"github.com/md-talim/codecrafters-redis-go/internal/store"
+)
+
+func TestReplConfListeningPort(t *testing.T) {
+	cmd := NewReplConfCommand(replication.NewMaster(1024))
+	s := session.Detached()
+	args := []resp.Value{
+		resp.NewBulkString("listening-port"),
+		resp.NewBulkString("6380"),
+	}
+
+	result := cmd.ExecuteWithSession(s, args)
+
+	if result.String() != "OK" {
+		t.Errorf("Expected 'OK', got %q", result.String())
+	}
+
+	if s.ListeningPort != "6380" {
+		t.Errorf("Expected listening port '6380', got %q", s.ListeningPort)
+	}
+}
+
+func TestPSyncFullResync(t *testing.T) {
+	databases := store.NewDatabases(1)
+	defer databases.Close()
+	databases.DB(0).Set("foo", []byte("bar"))
+
+	master := replication.NewMaster(1024)
+	cmd := NewPSyncCommand(databases, master)
+
+	serverConn, clientConn := net.Pipe()
+	defer clientConn.Close()
+
+	go func() {
+		defer serverConn.Close()
+		args := []resp.Value{resp.NewBulkString("?"), resp.NewBulkString("-1")}
+		cmd.ExecuteWithSession(session.New(1, serverConn), args)
+	}()
+
+	reader := bufio.NewReader(clientConn)
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		t.Fatalf("Failed to read: %v", err)
+	}
+
+	expected := fmt.Sprintf("+FULLRESYNC %s 0\r\n", master.ReplID())
+	if line != expected {
+		t.Errorf("Expected %q, got %q", expected, line)
+	}
+
+	line, err = reader.ReadString('\n')
+	if err != nil {
+		t.Fatalf("Failed to read: %v", err)
+	}
+
+	var length int
+	if _, err := fmt.Sscanf(line, "$%d\r\n", &length); err != nil {
+		t.Fatalf("Invalid bulk header %q", line)
+	}
+
+	snapshot, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("Failed to read snapshot: %v", err)
+	}
+
+	if len(snapshot) != length {
+		t.Errorf("Expected %d snapshot bytes, got %d", length, len(snapshot))
+	}
+
+	if !strings.HasPrefix(string(snapshot), rdb.RDBHeader) {
+		t.Errorf("Expected snapshot to start with %q", rdb.RDBHeader)
+	}
+
+	if !strings.Contains(string(snapshot), "foo") {
+		t.Errorf("Expected snapshot to contain key 'foo'")
+	}
+}
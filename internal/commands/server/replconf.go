@@ -0,0 +1,58 @@
+package server
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/md-talim/codecrafters-redis-go/internal/commands/core"
+	"github.com/md-talim/codecrafters-redis-go/internal/replication"
+	"github.com/md-talim/codecrafters-redis-go/internal/resp"
+	"github.com/md-talim/codecrafters-redis-go/internal/session"
+)
+
+type ReplConfCommand struct {
+	master *replication.Master
+}
+
+func NewReplConfCommand(master *replication.Master) *ReplConfCommand {
+	return &ReplConfCommand{master}
+}
+
+func (c *ReplConfCommand) Execute(args []resp.Value) resp.Value {
+	return c.ExecuteWithSession(session.Detached(), args)
+}
+
+func (c *ReplConfCommand) ExecuteWithSession(s *session.Session, args []resp.Value) resp.Value {
+	if len(args) == 0 || len(args)%2 != 0 {
+		return core.WrongNumberOfArgumentsError("replconf")
+	}
+
+	// Acknowledgements from replicas are never replied to
+	if strings.ToLower(args[0].String()) == "ack" {
+		offset, err := strconv.ParseInt(args[1].String(), 10, 64)
+		if err == nil {
+			c.master.Ack(s, offset)
+		}
+		return nil
+	}
+
+	for i := 0; i < len(args); i += 2 {
+		option := strings.ToLower(args[i].String())
+		value := args[i+1].String()
+
+		switch option {
+		case "listening-port":
+			s.ListeningPort = value
+		case "capa":
+			// Capabilities such as eof and psync2 need no special handling
+		default:
+			return resp.NewSimpleError("ERR Unrecognized REPLCONF option: " + option)
+		}
+	}
+
+	return resp.NewSimpleString("OK")
+}
+
+func (c *ReplConfCommand) Name() string {
+	return "REPLCONF"
+}
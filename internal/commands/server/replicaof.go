@@ -0,0 +1,44 @@
+package server
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/md-talim/codecrafters-redis-go/internal/commands/core"
+	"github.com/md-talim/codecrafters-redis-go/internal/replication"
+	"github.com/md-talim/codecrafters-redis-go/internal/resp"
+)
+
+type ReplicaOfCommand struct {
+	replication *replication.Manager
+}
+
+func NewReplicaOfCommand(replication *replication.Manager) *ReplicaOfCommand {
+	return &ReplicaOfCommand{replication}
+}
+
+func (c *ReplicaOfCommand) Execute(args []resp.Value) resp.Value {
+	if len(args) != 2 {
+		return core.WrongNumberOfArgumentsError("replicaof")
+	}
+
+	host, port := args[0].String(), args[1].String()
+	if strings.EqualFold(host, "no") && strings.EqualFold(port, "one") {
+		c.replication.PromoteToMaster()
+		return resp.NewSimpleString("OK")
+	}
+
+	if _, err := strconv.ParseUint(port, 10, 16); err != nil {
+		return resp.NewSimpleError("ERR Invalid master port")
+	}
+
+	if !c.replication.ReplicaOf(host, port) {
+		return resp.NewSimpleString("OK Already connected to specified master")
+	}
+
+	return resp.NewSimpleString("OK")
+}
+
+func (c *ReplicaOfCommand) Name() string {
+	return "REPLICAOF"
+}
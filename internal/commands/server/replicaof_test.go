@@ -0,0 +1,55 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/md-talim/codecrafters-redis-go/internal/config"
+	"github.com/md-talim/codecrafters-redis-go/internal/replication"
+	"github.com/md-talim/codecrafters-redis-go/internal/resp"
+)
+
+func TestReplicaOfAndPromote(t *testing.T) {
+	cfg := &config.Config{Port: "6380", ReplBacklogSize: 1024}
+	manager := replication.NewManager(cfg)
+	manager.Start(nil)
+	defer manager.PromoteToMaster()
+	cmd := NewReplicaOfCommand(manager)
+
+	result := cmd.Execute([]resp.Value{resp.NewBulkString("localhost"), resp.NewBulkString("1")})
+	if result.String() != "OK" {
+		t.Errorf("Expected 'OK', got %q", result.String())
+	}
+
+	if !cfg.IsReplica() || manager.Replica() == nil {
+		t.Fatal("Expected the server to be a replica")
+	}
+
+	host, port := cfg.GetMasterHostPort()
+	if host != "localhost" || port != "1" {
+		t.Errorf("Expected master localhost:1, got %s:%s", host, port)
+	}
+
+	result = cmd.Execute([]resp.Value{resp.NewBulkString("localhost"), resp.NewBulkString("1")})
+	if result.String() != "OK Already connected to specified master" {
+		t.Errorf("Expected already connected reply, got %q", result.String())
+	}
+
+	result = cmd.Execute([]resp.Value{resp.NewBulkString("NO"), resp.NewBulkString("ONE")})
+	if result.String() != "OK" {
+		t.Errorf("Expected 'OK', got %q", result.String())
+	}
+
+	if cfg.IsReplica() || manager.Replica() != nil {
+		t.Error("Expected the server to be a master")
+	}
+}
+
+func TestReplicaOfInvalidPort(t *testing.T) {
+	cfg := &config.Config{Port: "6380", ReplBacklogSize: 1024}
+	cmd := NewReplicaOfCommand(replication.NewManager(cfg))
+
+	result := cmd.Execute([]resp.Value{resp.NewBulkString("localhost"), resp.NewBulkString("port")})
+	if _, isError := result.(*resp.SimpleError); !isError {
+		t.Errorf("Expected SimpleError, got %q", result.String())
+	}
+}
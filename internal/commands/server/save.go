@@ -0,0 +1,91 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/md-talim/codecrafters-redis-go/internal/commands/core"
+	"github.com/md-talim/codecrafters-redis-go/internal/persistence"
+	"github.com/md-talim/codecrafters-redis-go/internal/resp"
+)
+
+type SaveCommand struct {
+	saver *persistence.Saver
+}
+
+func NewSaveCommand(saver *persistence.Saver) *SaveCommand {
+	return &SaveCommand{saver}
+}
+
+func (c *SaveCommand) Execute(args []resp.Value) resp.Value {
+	if len(args) != 0 {
+		return core.WrongNumberOfArgumentsError("save")
+	}
+
+	if err := c.saver.Save(); err != nil {
+		return saveError(err)
+	}
+
+	return resp.NewSimpleString("OK")
+}
+
+func (c *SaveCommand) Name() string {
+	return "SAVE"
+}
+
+type BgSaveCommand struct {
+	saver *persistence.Saver
+}
+
+func NewBgSaveCommand(saver *persistence.Saver) *BgSaveCommand {
+	return &BgSaveCommand{saver}
+}
+
+func (c *BgSaveCommand) Execute(args []resp.Value) resp.Value {
+	if len(args) > 1 {
+		return core.WrongNumberOfArgumentsError("bgsave")
+	}
+	// SCHEDULE is the only option and needs no handling, as nothing delays a
+	// background save here
+	if len(args) == 1 && !strings.EqualFold(args[0].String(), "SCHEDULE") {
+		return core.SyntaxError()
+	}
+
+	if err := c.saver.BackgroundSave(); err != nil {
+		return saveError(err)
+	}
+
+	return resp.NewSimpleString("Background saving started")
+}
+
+func (c *BgSaveCommand) Name() string {
+	return "BGSAVE"
+}
+
+type LastSaveCommand struct {
+	saver *persistence.Saver
+}
+
+func NewLastSaveCommand(saver *persistence.Saver) *LastSaveCommand {
+	return &LastSaveCommand{saver}
+}
+
+func (c *LastSaveCommand) Execute(args []resp.Value) resp.Value {
+	if len(args) != 0 {
+		return core.WrongNumberOfArgumentsError("lastsave")
+	}
+
+	return resp.NewInteger(fmt.Sprintf("%d", c.saver.LastSave().Unix()))
+}
+
+func (c *LastSaveCommand) Name() string {
+	return "LASTSAVE"
+}
+
+func saveError(err error) resp.Value {
+	if errors.Is(err, persistence.ErrSaveInProgress) {
+		return resp.NewSimpleError("ERR Background save already in progress")
+	}
+	return resp.NewSimpleError(fmt.Sprintf("ERR %v", err))
+}
@@ -0,0 +1,33 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/md-talim/codecrafters-redis-go/internal/config"
+	"github.com/md-talim/codecrafters-redis-go/internal/persistence"
+	"github.com/md-talim/codecrafters-redis-go/internal/resp"
+	"github.com/md-talim/codecrafters-redis-go/internal/store"
+)
+
+func TestBgSaveArguments(t *testing.T) {
+	cfg := &config.Config{Dir: t.TempDir(), DBFilename: "dump.rdb"}
+	saver := persistence.NewSaver(store.NewDatabases(1), cfg, func(fn func()) { fn() })
+	cmd := NewBgSaveCommand(saver)
+
+	if result := cmd.Execute([]resp.Value{resp.NewBulkString("foo")}); result.String() != "ERR syntax error" {
+		t.Errorf("Expected 'ERR syntax error', got %q", result.String())
+	}
+	if saver.BackgroundSaveInProgress() {
+		t.Error("Expected no save to start after a syntax error")
+	}
+
+	if result := cmd.Execute([]resp.Value{resp.NewBulkString("schedule")}); result.String() != "Background saving started" {
+		t.Errorf("Expected 'Background saving started', got %q", result.String())
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for saver.BackgroundSaveInProgress() && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+}
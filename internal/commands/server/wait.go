@@ -0,0 +1,45 @@
+package server
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/md-talim/codecrafters-redis-go/internal/commands/core"
+	"github.com/md-talim/codecrafters-redis-go/internal/replication"
+	"github.com/md-talim/codecrafters-redis-go/internal/resp"
+)
+
+type WaitCommand struct {
+	master *replication.Master
+}
+
+func NewWaitCommand(master *replication.Master) *WaitCommand {
+	return &WaitCommand{master}
+}
+
+func (c *WaitCommand) Execute(args []resp.Value) resp.Value {
+	if len(args) != 2 {
+		return core.WrongNumberOfArgumentsError("wait")
+	}
+
+	numReplicas, err := strconv.Atoi(args[0].String())
+	if err != nil || numReplicas < 0 {
+		return core.ValueNotIntegerError()
+	}
+
+	timeout, err := strconv.ParseInt(args[1].String(), 10, 64)
+	if err != nil {
+		return core.ValueNotIntegerError()
+	}
+	if timeout < 0 {
+		return resp.NewSimpleError("ERR timeout is negative")
+	}
+
+	acknowledged := c.master.Wait(numReplicas, time.Duration(timeout)*time.Millisecond)
+	return resp.NewInteger(fmt.Sprintf("%d", acknowledged))
+}
+
+func (c *WaitCommand) Name() string {
+	return "WAIT"
+}
@@ -2,14 +2,64 @@ package config
 
 import (
 	"flag"
+	"fmt"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
 )
 
+const (
+	defaultReplBacklogSize = 1024 * 1024
+	defaultDatabases       = 16
+
+	defaultAutoAOFRewriteMinSize = 64 * 1024 * 1024
+
+	defaultSave = "3600 1 300 100 60 10000"
+)
+
+// SavePoint triggers a background save once at least Changes writes were
+// made and Seconds passed since the last save.
+type SavePoint struct {
+	Seconds int
+	Changes int64
+}
+
 type Config struct {
-	Dir        string
-	DBFilename string
-	Port       string
-	ReplicaOf  string
+	Dir             string
+	DBFilename      string
+	Port            string
+	Databases       int
+	ReplicaOf       string
+	ReplBacklogSize int64
+	ReplicaReadOnly bool
+
+	// SavePoints are the rules for saving the RDB file in the background.
+	// An empty list disables them.
+	SavePoints []SavePoint
+
+	// RDBSkipZeroChecksum loads RDB files whose checksum footer is zero
+	// without verifying them
+	RDBSkipZeroChecksum bool
+
+	AppendOnly        bool
+	AppendFilename    string
+	AppendDirname     string
+	AppendFsync       string
+	AOFUseRDBPreamble bool
+
+	// AutoAOFRewritePercentage triggers a rewrite once the append only file
+	// grew by this percentage since the last one, if it is at least
+	// AutoAOFRewriteMinSize bytes. Zero disables automatic rewrites.
+	AutoAOFRewritePercentage int
+	AutoAOFRewriteMinSize    int64
+
+	// AOFLoadTruncated loads an append only file whose last command was cut
+	// short instead of refusing to start
+	AOFLoadTruncated bool
+
+	// mu guards the settings that can change at runtime
+	mu sync.RWMutex
 }
 
 var instance *Config
@@ -22,15 +72,44 @@ func Load() *Config {
 	dir := flag.String("dir", "/tmp", "Directory for RDB file")
 	dbfilename := flag.String("dbfilename", "dump.rdb", "RDB filename")
 	port := flag.String("port", "6379", "Port to listen on")
+	databases := flag.Int("databases", defaultDatabases, "Number of databases")
 	replicaOf := flag.String("replicaof", "", "Make this instance a replica of <host> <port>")
+	replBacklogSize := memoryFlag("repl-backlog-size", defaultReplBacklogSize, "Size of the replication backlog")
+	replicaReadOnly := yesNoFlag("replica-read-only", true, "Reject writes from clients while acting as a replica")
+	savePoints := savePointsFlag("save", defaultSave, "Save the RDB file after <seconds> <changes>, repeated for each rule, or \"\" to disable")
+	rdbSkipZeroChecksum := yesNoFlag("rdb-skip-zero-checksum", true, "Load RDB files with a zero checksum without verifying them")
+	appendOnly := yesNoFlag("appendonly", false, "Log every write to the append only file")
+	appendFilename := flag.String("appendfilename", "appendonly.aof", "Append only filename")
+	appendDirname := flag.String("appenddirname", "appendonlydir", "Directory holding the append only files, inside dir")
+	appendFsync := choiceFlag("appendfsync", "everysec", []string{"always", "everysec", "no"}, "How often the append only file is synced to disk")
+	aofUseRDBPreamble := yesNoFlag("aof-use-rdb-preamble", true, "Write the base append only file in RDB format")
+	autoAOFRewritePercentage := flag.Int("auto-aof-rewrite-percentage", 100, "Growth since the last rewrite that triggers an append only file rewrite")
+	autoAOFRewriteMinSize := memoryFlag("auto-aof-rewrite-min-size", defaultAutoAOFRewriteMinSize, "Minimum append only file size for an automatic rewrite")
+	aofLoadTruncated := yesNoFlag("aof-load-truncated", true, "Load an append only file with a truncated last command")
 
 	flag.Parse()
 
 	instance = &Config{
-		Dir:        *dir,
-		DBFilename: *dbfilename,
-		Port:       *port,
-		ReplicaOf:  *replicaOf,
+		Dir:             *dir,
+		DBFilename:      *dbfilename,
+		Port:            *port,
+		Databases:       *databases,
+		ReplicaOf:       *replicaOf,
+		ReplBacklogSize: *replBacklogSize,
+		ReplicaReadOnly: *replicaReadOnly,
+		SavePoints:      *savePoints,
+
+		RDBSkipZeroChecksum: *rdbSkipZeroChecksum,
+
+		AppendOnly:        *appendOnly,
+		AppendFilename:    *appendFilename,
+		AppendDirname:     *appendDirname,
+		AppendFsync:       *appendFsync,
+		AOFUseRDBPreamble: *aofUseRDBPreamble,
+		AOFLoadTruncated:  *aofLoadTruncated,
+
+		AutoAOFRewritePercentage: *autoAOFRewritePercentage,
+		AutoAOFRewriteMinSize:    *autoAOFRewriteMinSize,
 	}
 
 	return instance
@@ -51,24 +130,195 @@ func (c *Config) GetParameter(param string) (string, bool) {
 		return c.DBFilename, true
 	case "port":
 		return c.Port, true
+	case "databases":
+		return strconv.Itoa(c.Databases), true
+	case "repl-backlog-size":
+		return strconv.FormatInt(c.ReplBacklogSize, 10), true
+	case "replicaof":
+		c.mu.RLock()
+		defer c.mu.RUnlock()
+		return c.ReplicaOf, true
+	case "replica-read-only":
+		return formatYesNo(c.ReplicaReadOnly), true
+	case "save":
+		return FormatSavePoints(c.SavePoints), true
+	case "rdb-skip-zero-checksum":
+		return formatYesNo(c.RDBSkipZeroChecksum), true
+	case "appendonly":
+		return formatYesNo(c.AppendOnly), true
+	case "appendfilename":
+		return c.AppendFilename, true
+	case "appenddirname":
+		return c.AppendDirname, true
+	case "appendfsync":
+		return c.AppendFsync, true
+	case "aof-use-rdb-preamble":
+		return formatYesNo(c.AOFUseRDBPreamble), true
+	case "auto-aof-rewrite-percentage":
+		return strconv.Itoa(c.AutoAOFRewritePercentage), true
+	case "auto-aof-rewrite-min-size":
+		return strconv.FormatInt(c.AutoAOFRewriteMinSize, 10), true
+	case "aof-load-truncated":
+		return formatYesNo(c.AOFLoadTruncated), true
 	default:
 		return "", false
 	}
 }
 
 func (c *Config) IsReplica() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
 	return c.ReplicaOf != ""
 }
 
+// SetReplicaOf changes the master this instance replicates from. An empty
+// value makes it a master.
+func (c *Config) SetReplicaOf(replicaOf string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.ReplicaOf = replicaOf
+}
+
 func (c *Config) GetMasterHostPort() (string, string) {
-	if !c.IsReplica() {
-		return "", ""
-	}
+	c.mu.RLock()
+	replicaOf := c.ReplicaOf
+	c.mu.RUnlock()
 
-	parts := strings.Fields(c.ReplicaOf)
+	parts := strings.Fields(replicaOf)
 	if len(parts) != 2 {
 		return "", ""
 	}
 
 	return parts[0], parts[1]
 }
+
+// ParseMemory parses a size such as "512", "64kb" or "1mb" into bytes.
+func ParseMemory(value string) (int64, error) {
+	units := []struct {
+		suffix     string
+		multiplier int64
+	}{
+		{"gb", 1024 * 1024 * 1024},
+		{"mb", 1024 * 1024},
+		{"kb", 1024},
+		{"g", 1000 * 1000 * 1000},
+		{"m", 1000 * 1000},
+		{"k", 1000},
+		{"b", 1},
+	}
+
+	value = strings.ToLower(strings.TrimSpace(value))
+	multiplier := int64(1)
+	for _, unit := range units {
+		if strings.HasSuffix(value, unit.suffix) {
+			value = strings.TrimSuffix(value, unit.suffix)
+			multiplier = unit.multiplier
+			break
+		}
+	}
+
+	size, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || size < 0 {
+		return 0, fmt.Errorf("invalid memory size: %q", value)
+	}
+
+	return size * multiplier, nil
+}
+
+// ParseSavePoints parses save rules such as "900 1 300 10", a number of
+// seconds followed by a number of changes for each rule.
+func ParseSavePoints(value string) ([]SavePoint, error) {
+	fields := strings.Fields(value)
+	if len(fields)%2 != 0 {
+		return nil, fmt.Errorf("invalid save rules: %q", value)
+	}
+
+	var points []SavePoint
+	for i := 0; i < len(fields); i += 2 {
+		seconds, err := strconv.Atoi(fields[i])
+		if err != nil || seconds < 1 {
+			return nil, fmt.Errorf("invalid save seconds: %q", fields[i])
+		}
+		changes, err := strconv.ParseInt(fields[i+1], 10, 64)
+		if err != nil || changes < 0 {
+			return nil, fmt.Errorf("invalid save changes: %q", fields[i+1])
+		}
+		points = append(points, SavePoint{Seconds: seconds, Changes: changes})
+	}
+
+	return points, nil
+}
+
+// FormatSavePoints formats save rules the way ParseSavePoints reads them.
+func FormatSavePoints(points []SavePoint) string {
+	fields := make([]string, 0, len(points)*2)
+	for _, point := range points {
+		fields = append(fields, strconv.Itoa(point.Seconds), strconv.FormatInt(point.Changes, 10))
+	}
+	return strings.Join(fields, " ")
+}
+
+func savePointsFlag(name, defaultValue string, usage string) *[]SavePoint {
+	points, err := ParseSavePoints(defaultValue)
+	if err != nil {
+		panic(err)
+	}
+	flag.Func(name, usage, func(value string) error {
+		parsed, err := ParseSavePoints(value)
+		if err != nil {
+			return err
+		}
+		points = parsed
+		return nil
+	})
+	return &points
+}
+
+func memoryFlag(name string, defaultValue int64, usage string) *int64 {
+	size := defaultValue
+	flag.Func(name, usage, func(value string) error {
+		parsed, err := ParseMemory(value)
+		if err != nil {
+			return err
+		}
+		size = parsed
+		return nil
+	})
+	return &size
+}
+
+func yesNoFlag(name string, defaultValue bool, usage string) *bool {
+	enabled := defaultValue
+	flag.Func(name, usage+" (yes|no)", func(value string) error {
+		switch strings.ToLower(value) {
+		case "yes":
+			enabled = true
+		case "no":
+			enabled = false
+		default:
+			return fmt.Errorf("expected yes or no, got %q", value)
+		}
+		return nil
+	})
+	return &enabled
+}
+
+func choiceFlag(name, defaultValue string, choices []string, usage string) *string {
+	chosen := defaultValue
+	flag.Func(name, usage+" ("+strings.Join(choices, "|")+")", func(value string) error {
+		value = strings.ToLower(value)
+		if !slices.Contains(choices, value) {
+			return fmt.Errorf("expected one of %s, got %q", strings.Join(choices, ", "), value)
+		}
+		chosen = value
+		return nil
+	})
+	return &chosen
+}
+
+func formatYesNo(enabled bool) string {
+	if enabled {
+		return "yes"
+	}
+	return "no"
+}
@@ -0,0 +1,56 @@
+package config
+
+import "testing"
+
+func TestParseMemory(t *testing.T) {
+	tests := map[string]int64{
+		"512":  512,
+		"1mb":  1024 * 1024,
+		"64KB": 64 * 1024,
+		"1gb":  1024 * 1024 * 1024,
+		"2k":   2000,
+	}
+
+	for input, expected := range tests {
+		size, err := ParseMemory(input)
+		if err != nil {
+			t.Errorf("ParseMemory(%q) failed: %v", input, err)
+			continue
+		}
+		if size != expected {
+			t.Errorf("ParseMemory(%q): expected %d, got %d", input, expected, size)
+		}
+	}
+}
+
+func TestParseMemoryInvalid(t *testing.T) {
+	for _, input := range []string{"", "mb", "-1", "1tb"} {
+		if _, err := ParseMemory(input); err == nil {
+			t.Errorf("ParseMemory(%q): expected error", input)
+		}
+	}
+}
+
+func TestParseSavePoints(t *testing.T) {
+	points, err := ParseSavePoints("900 1  300 10")
+	if err != nil {
+		t.Fatalf("ParseSavePoints failed: %v", err)
+	}
+	expected := []SavePoint{{Seconds: 900, Changes: 1}, {Seconds: 300, Changes: 10}}
+	if len(points) != len(expected) || points[0] != expected[0] || points[1] != expected[1] {
+		t.Errorf("Expected %v, got %v", expected, points)
+	}
+	if formatted := FormatSavePoints(points); formatted != "900 1 300 10" {
+		t.Errorf("Expected '900 1 300 10', got %q", formatted)
+	}
+
+	if points, err := ParseSavePoints(""); err != nil || len(points) != 0 {
+		t.Errorf("Expected an empty value to disable saving, got %v, %v", points, err)
+	}
+
+	for _, input := range []string{"900", "x 1", "900 y", "0 1", "900 -1"} {
+		if _, err := ParseSavePoints(input); err == nil {
+			t.Errorf("ParseSavePoints(%q): expected error", input)
+		}
+	}
+}
@@ -0,0 +1,732 @@
+package persistence
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/md-talim/codecrafters-redis-go/internal/config"
+	"github.com/md-talim/codecrafters-redis-go/internal/rdb"
+	"github.com/md-talim/codecrafters-redis-go/internal/resp"
+	"github.com/md-talim/codecrafters-redis-go/internal/session"
+	"github.com/md-talim/codecrafters-redis-go/internal/store"
+)
+
+// appendfsync policies
+const (
+	FsyncAlways   = "always"
+	FsyncEverySec = "everysec"
+	FsyncNo       = "no"
+)
+
+// Delays before an automatic rewrite is retried after a failure, doubling
+// from the minimum with each failure in a row, as in Redis.
+const (
+	minRewriteRetryDelay = time.Minute
+	maxRewriteRetryDelay = time.Hour
+)
+
+var ErrRewriteInProgress = errors.New("background append only file rewriting already in progress")
+
+// AOF logs every write command to the append only file, so the dataset can
+// be rebuilt by replaying it. The file is made of several parts listed in a
+// manifest: a base file written by the last rewrite, in RDB or AOF format,
+// and incremental files holding the commands logged since.
+type AOF struct {
+	config    *config.Config
+	databases *store.Databases
+
+	// exclusive runs a function while no write command executes
+	exclusive func(func())
+
+	manifest *manifest
+
+	// file is the incremental file commands are appended to
+	file *os.File
+
+	// db is the database selected in the file, or -1 before the first write
+	db int
+
+	// dirty reports whether writes are waiting for the next everysec fsync
+	dirty        bool
+	lastWriteErr error
+
+	// baseSize is the size of the append only file after the last rewrite,
+	// which currentSize is compared with to trigger the next one
+	baseSize    int64
+	currentSize int64
+
+	rewriting      bool
+	lastRewriteErr error
+
+	// rewriteFailures counts the automatic rewrites that failed in a row,
+	// each one doubling the delay before the next is attempted
+	rewriteFailures   int
+	nextAutoRewriteAt time.Time
+
+	mu     sync.Mutex
+	closer chan struct{}
+}
+
+// NewAOF returns an append only file for the databases. exclusive must run
+// its argument while no write command executes, so a rewrite snapshot and
+// the switch to a new incremental file see the same writes.
+func NewAOF(config *config.Config, databases *store.Databases, exclusive func(func())) *AOF {
+	return &AOF{
+		config:    config,
+		databases: databases,
+		exclusive: exclusive,
+		db:        -1,
+		closer:    make(chan struct{}),
+	}
+}
+
+func (a *AOF) dir() string {
+	return filepath.Join(a.config.Dir, a.config.AppendDirname)
+}
+
+func (a *AOF) path(name string) string {
+	return filepath.Join(a.dir(), name)
+}
+
+func (a *AOF) manifestPath() string {
+	return a.path(a.config.AppendFilename + ".manifest")
+}
+
+// legacyPath is where a single file append only file was kept before the
+// multi-part layout.
+func (a *AOF) legacyPath() string {
+	return filepath.Join(a.config.Dir, a.config.AppendFilename)
+}
+
+// Exists reports whether there is an append only file to load.
+func (a *AOF) Exists() bool {
+	for _, path := range []string{a.manifestPath(), a.legacyPath()} {
+		if _, err := os.Stat(path); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// Load rebuilds the dataset from the base file and replays the incremental
+// files through apply. SELECT in a file applies until the end of that file.
+// A command cut short at the end of the last file is dropped, and the file
+// truncated, when aof-load-truncated is enabled.
+func (a *AOF) Load(apply func(*session.Session, resp.Value) resp.Value) (int, error) {
+	if err := a.upgradeLegacy(); err != nil {
+		return 0, err
+	}
+
+	m, err := readManifest(a.manifestPath())
+	if err != nil {
+		return 0, fmt.Errorf("failed to read manifest: %w", err)
+	}
+	if m == nil {
+		return 0, nil
+	}
+
+	a.mu.Lock()
+	a.manifest = m
+	a.mu.Unlock()
+
+	commands := 0
+	files := m.files()
+	for i, file := range files {
+		last := i == len(files)-1
+		if strings.HasSuffix(file.name, ".rdb") {
+			err = a.loadRDB(file.name)
+		} else {
+			var replayed int
+			replayed, err = a.replay(file.name, last, apply)
+			commands += replayed
+		}
+		if err != nil {
+			return commands, fmt.Errorf("%s: %w", file.name, err)
+		}
+	}
+
+	return commands, nil
+}
+
+// upgradeLegacy moves a single file append only file into the directory as
+// the base of a new manifest.
+func (a *AOF) upgradeLegacy() error {
+	if _, err := os.Stat(a.manifestPath()); err == nil {
+		return nil
+	}
+	if _, err := os.Stat(a.legacyPath()); err != nil {
+		return nil
+	}
+
+	if err := os.MkdirAll(a.dir(), 0o755); err != nil {
+		return fmt.Errorf("failed to create append only directory: %w", err)
+	}
+	if err := os.Rename(a.legacyPath(), a.path(a.config.AppendFilename)); err != nil {
+		return fmt.Errorf("failed to move append only file: %w", err)
+	}
+
+	m := &manifest{base: &aofFile{name: a.config.AppendFilename, seq: 1, kind: aofBase}}
+	return m.write(a.manifestPath())
+}
+
+func (a *AOF) loadRDB(name string) error {
+	reader, err := rdb.NewReader(a.dir(), name)
+	if err != nil {
+		return err
+	}
+	if reader == nil {
+		return fmt.Errorf("base file is missing")
+	}
+	defer reader.Close()
+	reader.SkipZeroChecksum(a.config.RDBSkipZeroChecksum)
+
+	_, err = store.LoadRDB(a.databases, reader)
+	return err
+}
+
+func (a *AOF) replay(name string, last bool, apply func(*session.Session, resp.Value) resp.Value) (int, error) {
+	path := a.path(name)
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	replay := session.Detached()
+	parser := resp.NewParser(bufio.NewReader(file))
+	commands := 0
+
+	for {
+		offset := parser.BytesRead()
+		command, err := parser.Parse()
+		if err == io.EOF && parser.BytesRead() == offset {
+			return commands, nil
+		}
+
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			if !last || !a.config.AOFLoadTruncated {
+				return commands, fmt.Errorf("unexpected end of file at byte %d", offset)
+			}
+			fmt.Printf("Warning: truncated append only file %s, discarding the last command at byte %d\n", name, offset)
+			if err := os.Truncate(path, offset); err != nil {
+				return commands, fmt.Errorf("failed to truncate: %w", err)
+			}
+			return commands, nil
+		}
+		if err != nil {
+			return commands, fmt.Errorf("bad format at byte %d: %w", offset, err)
+		}
+
+		if result, isError := apply(replay, command).(*resp.SimpleError); isError {
+			fmt.Printf("Error replaying %s at byte %d: %s\n", name, offset, result.String())
+		}
+		commands++
+	}
+}
+
+// Open starts logging commands, appending to the last incremental file.
+// Commands appended before Open, such as those replayed by Load, are not
+// logged. Without a manifest, the current dataset is written as the base of
+// a new one.
+func (a *AOF) Open() error {
+	if err := os.MkdirAll(a.dir(), 0o755); err != nil {
+		return fmt.Errorf("failed to create append only directory: %w", err)
+	}
+
+	a.mu.Lock()
+	m := a.manifest
+	a.mu.Unlock()
+
+	if m == nil {
+		if err := a.rewrite(); err != nil {
+			return err
+		}
+	} else if err := a.openIncremental(); err != nil {
+		return err
+	}
+
+	if a.config.AppendFsync == FsyncEverySec {
+		go a.syncEverySecond()
+	}
+	return nil
+}
+
+// openIncremental opens the last incremental file of the loaded manifest,
+// adding one if there is none.
+func (a *AOF) openIncremental() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if len(a.manifest.incrementals) == 0 {
+		file, part, err := a.createIncremental(a.manifest)
+		if err != nil {
+			return err
+		}
+		a.manifest.incrementals = append(a.manifest.incrementals, part)
+		if err := a.manifest.write(a.manifestPath()); err != nil {
+			file.Close()
+			return err
+		}
+		a.file = file
+	} else {
+		last := a.manifest.incrementals[len(a.manifest.incrementals)-1]
+		file, err := os.OpenFile(a.path(last.name), os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+		if err != nil {
+			return fmt.Errorf("failed to open append only file: %w", err)
+		}
+		a.file = file
+	}
+
+	a.currentSize = a.totalSize(a.manifest)
+	a.baseSize = a.currentSize
+	return nil
+}
+
+func (a *AOF) createIncremental(m *manifest) (*os.File, aofFile, error) {
+	seq := m.nextIncrementalSeq()
+	part := aofFile{name: fmt.Sprintf("%s.%d.incr.aof", a.config.AppendFilename, seq), seq: seq, kind: aofIncremental}
+
+	file, err := os.OpenFile(a.path(part.name), os.O_WRONLY|os.O_APPEND|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return nil, part, fmt.Errorf("failed to create incremental file: %w", err)
+	}
+	return file, part, nil
+}
+
+func (a *AOF) totalSize(m *manifest) int64 {
+	var size int64
+	for _, file := range m.files() {
+		if info, err := os.Stat(a.path(file.name)); err == nil {
+			size += info.Size()
+		}
+	}
+	return size
+}
+
+// Append logs a write command executed against database db, preceded by a
+// SELECT when the file was on another database. Relative expiries are made
+// absolute so replaying the log later does not extend them.
+func (a *AOF) Append(db int, command []resp.Value) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.file == nil {
+		return
+	}
+
+	var data []byte
+	if db != a.db {
+		data = append(data, encodeCommand("SELECT", strconv.Itoa(db))...)
+	}
+	data = append(data, resp.NewArray(absoluteExpiry(command)).Serialize()...)
+
+	if _, err := a.file.Write(data); err != nil {
+		a.lastWriteErr = err
+		fmt.Printf("Failed to write to the append only file: %v\n", err)
+		return
+	}
+	a.db = db
+	a.currentSize += int64(len(data))
+
+	switch a.config.AppendFsync {
+	case FsyncAlways:
+		a.lastWriteErr = a.file.Sync()
+	case FsyncEverySec:
+		a.dirty = true
+		a.lastWriteErr = nil
+	default:
+		a.lastWriteErr = nil
+	}
+
+	if a.shouldRewrite() {
+		// The rewrite waits for the write being logged to finish
+		go a.Rewrite()
+	}
+}
+
+// shouldRewrite reports whether the file grew past auto-aof-rewrite-min-size
+// and by auto-aof-rewrite-percentage since the last rewrite. After a failed
+// rewrite it waits out a delay, so a rewrite that keeps failing is not
+// retried on every write.
+func (a *AOF) shouldRewrite() bool {
+	percentage := a.config.AutoAOFRewritePercentage
+	if percentage <= 0 || a.rewriting || a.currentSize < a.config.AutoAOFRewriteMinSize {
+		return false
+	}
+	if time.Now().Before(a.nextAutoRewriteAt) {
+		return false
+	}
+
+	base := max(a.baseSize, 1)
+	return (a.currentSize-base)*100/base >= int64(percentage)
+}
+
+// Rewrite compacts the append only file in the background. It returns
+// ErrRewriteInProgress if a rewrite is already running.
+func (a *AOF) Rewrite() error {
+	a.mu.Lock()
+	if a.rewriting {
+		a.mu.Unlock()
+		return ErrRewriteInProgress
+	}
+	a.rewriting = true
+	a.mu.Unlock()
+
+	snapshot, err := a.switchIncremental()
+	if err != nil {
+		a.finishRewrite(err)
+		return err
+	}
+
+	go func() {
+		a.finishRewrite(a.writeBase(snapshot))
+	}()
+	return nil
+}
+
+// rewrite compacts the append only file, blocking until it is done.
+func (a *AOF) rewrite() error {
+	a.mu.Lock()
+	if a.rewriting {
+		a.mu.Unlock()
+		return ErrRewriteInProgress
+	}
+	a.rewriting = true
+	a.mu.Unlock()
+
+	snapshot, err := a.switchIncremental()
+	if err == nil {
+		err = a.writeBase(snapshot)
+	}
+	a.finishRewrite(err)
+	return err
+}
+
+// baseSnapshot is the dataset a rewrite writes as the new base file, with
+// the manifest that replaces the current one once it is written.
+type baseSnapshot struct {
+	databases []map[string]store.Item
+	manifest  *manifest
+}
+
+// switchIncremental takes a snapshot of the dataset and starts logging to a
+// new incremental file at the same point, so writes made while the base file
+// is written are kept. Until then the manifest lists the new incremental
+// file after the old ones, so a restart still loads every write.
+func (a *AOF) switchIncremental() (*baseSnapshot, error) {
+	var snapshot *baseSnapshot
+	var err error
+
+	a.exclusive(func() {
+		a.mu.Lock()
+		defer a.mu.Unlock()
+
+		current := a.manifest
+		if current == nil {
+			current = &manifest{}
+		}
+
+		file, part, createErr := a.createIncremental(current)
+		if createErr != nil {
+			err = createErr
+			return
+		}
+
+		interim := &manifest{base: current.base, incrementals: append(append([]aofFile{}, current.incrementals...), part)}
+		if err = interim.write(a.manifestPath()); err != nil {
+			file.Close()
+			os.Remove(a.path(part.name))
+			return
+		}
+
+		if a.file != nil {
+			a.file.Sync()
+			a.file.Close()
+		}
+		a.file = file
+		a.db = -1
+		a.manifest = interim
+
+		seq := current.nextBaseSeq()
+		extension := "aof"
+		if a.config.AOFUseRDBPreamble {
+			extension = "rdb"
+		}
+		base := &aofFile{name: fmt.Sprintf("%s.%d.base.%s", a.config.AppendFilename, seq, extension), seq: seq, kind: aofBase}
+
+		snapshot = &baseSnapshot{
+			databases: a.databases.Snapshot(),
+			manifest:  &manifest{base: base, incrementals: []aofFile{part}},
+		}
+	})
+
+	return snapshot, err
+}
+
+// writeBase writes the snapshot as the new base file, then replaces the
+// manifest and removes the files it no longer lists.
+func (a *AOF) writeBase(snapshot *baseSnapshot) error {
+	name := snapshot.manifest.base.name
+	if err := writeBaseFile(a.path(name), a.config.AOFUseRDBPreamble, snapshot.databases); err != nil {
+		return err
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if err := snapshot.manifest.write(a.manifestPath()); err != nil {
+		os.Remove(a.path(name))
+		return err
+	}
+
+	obsolete := a.manifest
+	a.manifest = snapshot.manifest
+	kept := a.manifest.files()
+	for _, file := range obsolete.files() {
+		if !slices.ContainsFunc(kept, func(k aofFile) bool { return k.name == file.name }) {
+			os.Remove(a.path(file.name))
+		}
+	}
+
+	a.currentSize = a.totalSize(a.manifest)
+	a.baseSize = a.currentSize
+	return nil
+}
+
+func (a *AOF) finishRewrite(err error) {
+	if err != nil {
+		fmt.Printf("Background append only file rewriting error: %v\n", err)
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.rewriting = false
+	a.lastRewriteErr = err
+
+	if err == nil {
+		a.rewriteFailures = 0
+		a.nextAutoRewriteAt = time.Time{}
+		return
+	}
+	a.rewriteFailures++
+	delay := min(minRewriteRetryDelay<<min(a.rewriteFailures-1, 6), maxRewriteRetryDelay)
+	a.nextAutoRewriteAt = time.Now().Add(delay)
+}
+
+// writeBaseFile writes the dataset to path through a temporary file, in RDB
+// format or as the commands that recreate it.
+func writeBaseFile(path string, rdbFormat bool, databases []map[string]store.Item) error {
+	file, err := os.CreateTemp(filepath.Dir(path), "temp-rewrite-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer os.Remove(file.Name())
+
+	writer := bufio.NewWriter(file)
+	if rdbFormat {
+		err = store.WriteSnapshot(databases, writer)
+	} else {
+		err = writeCommands(databases, writer)
+	}
+	if err == nil {
+		err = writer.Flush()
+	}
+	if err == nil {
+		err = file.Chmod(0o644)
+	}
+	if err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write base file: %w", err)
+	}
+
+	return os.Rename(file.Name(), path)
+}
+
+// writeCommands writes the commands that recreate the dataset. Strings and
+// lists without an expiry are written as SET and RPUSH, and every other value
+// as a RESTORE of its DUMP payload, which carries the expiry as ABSTTL.
+func writeCommands(databases []map[string]store.Item, w io.Writer) error {
+	for db, items := range databases {
+		if len(items) == 0 {
+			continue
+		}
+		if _, err := w.Write(encodeCommand("SELECT", strconv.Itoa(db))); err != nil {
+			return err
+		}
+
+		for key, item := range items {
+			var command []string
+			switch value := item.Value.(type) {
+			case []byte:
+				command = []string{"SET", key, string(value)}
+				if item.ExpriesAt != nil {
+					command = append(command, "PXAT", strconv.FormatInt(item.ExpriesAt.UnixMilli(), 10))
+				}
+			case *store.List:
+				if item.ExpriesAt == nil {
+					command = []string{"RPUSH", key}
+					for _, element := range value.Range(0, value.Size()) {
+						command = append(command, element.String())
+					}
+				}
+			}
+			// Anything else is restored from its DUMP payload
+			if command == nil {
+				var err error
+				if command, err = restoreCommand(key, item); err != nil {
+					return err
+				}
+			}
+
+			if _, err := w.Write(encodeCommand(command...)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// restoreCommand returns the RESTORE command that recreates an item from
+// its DUMP payload, with an absolute expiry.
+func restoreCommand(key string, item store.Item) ([]string, error) {
+	payload, err := store.Dump(item.Value)
+	if err != nil {
+		return nil, fmt.Errorf("cannot rewrite key %q: %w", key, err)
+	}
+
+	expiresAt := int64(0)
+	if item.ExpriesAt != nil {
+		expiresAt = item.ExpriesAt.UnixMilli()
+	}
+	return []string{"RESTORE", key, strconv.FormatInt(expiresAt, 10), string(payload), "ABSTTL", "REPLACE"}, nil
+}
+
+// RewriteInProgress reports whether a rewrite is running.
+func (a *AOF) RewriteInProgress() bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.rewriting
+}
+
+// LastRewriteStatus returns the error of the last rewrite, or nil if it
+// succeeded.
+func (a *AOF) LastRewriteStatus() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.lastRewriteErr
+}
+
+// Sizes returns the current size of the append only file and its size after
+// the last rewrite.
+func (a *AOF) Sizes() (current, base int64) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.currentSize, a.baseSize
+}
+
+// LastWriteStatus returns the error of the last write or fsync, or nil if it
+// succeeded.
+func (a *AOF) LastWriteStatus() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.lastWriteErr
+}
+
+// Close syncs and closes the append only file.
+func (a *AOF) Close() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.file == nil {
+		return nil
+	}
+	close(a.closer)
+
+	err := a.file.Sync()
+	if closeErr := a.file.Close(); err == nil {
+		err = closeErr
+	}
+	a.file = nil
+	return err
+}
+
+func (a *AOF) syncEverySecond() {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			a.mu.Lock()
+			if a.dirty && a.file != nil {
+				a.lastWriteErr = a.file.Sync()
+				a.dirty = false
+			}
+			a.mu.Unlock()
+		case <-a.closer:
+			return
+		}
+	}
+}
+
+// absoluteExpiry rewrites SET key value PX ms as SET key value PXAT ts, and
+// RESTORE key ttl payload as RESTORE key ts payload ABSTTL.
+func absoluteExpiry(command []resp.Value) []resp.Value {
+	if len(command) == 0 {
+		return command
+	}
+
+	switch strings.ToUpper(command[0].String()) {
+	case "SET":
+		rewritten := slices.Clone(command)
+		for i := 3; i < len(rewritten)-1; i++ {
+			if !strings.EqualFold(rewritten[i].String(), "PX") {
+				continue
+			}
+			milliseconds, err := strconv.ParseInt(rewritten[i+1].String(), 10, 64)
+			if err != nil {
+				continue
+			}
+			rewritten[i] = resp.NewBulkString("PXAT")
+			rewritten[i+1] = resp.NewBulkString(strconv.FormatInt(time.Now().UnixMilli()+milliseconds, 10))
+		}
+		return rewritten
+	case "RESTORE":
+		if len(command) < 4 || slices.ContainsFunc(command[4:], func(arg resp.Value) bool {
+			return strings.EqualFold(arg.String(), "ABSTTL")
+		}) {
+			return command
+		}
+		ttl, err := strconv.ParseInt(command[2].String(), 10, 64)
+		if err != nil || ttl == 0 {
+			return command
+		}
+		rewritten := slices.Clone(command)
+		rewritten[2] = resp.NewBulkString(strconv.FormatInt(time.Now().UnixMilli()+ttl, 10))
+		return append(rewritten, resp.NewBulkString("ABSTTL"))
+	default:
+		return command
+	}
+}
+
+func encodeCommand(args ...string) []byte {
+	items := make([]resp.Value, len(args))
+	for i, arg := range args {
+		items[i] = resp.NewBulkString(arg)
+	}
+	return resp.NewArray(items).Serialize()
+}
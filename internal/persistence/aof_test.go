@@ -0,0 +1,352 @@
+package persistence
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/md-talim/codecrafters-redis-go/internal/config"
+	"github.com/md-talim/codecrafters-redis-go/internal/resp"
+	"github.com/md-talim/codecrafters-redis-go/internal/session"
+	"github.com/md-talim/codecrafters-redis-go/internal/store"
+)
+
+func aofConfig(t *testing.T) *config.Config {
+	return &config.Config{Dir: t.TempDir(), AppendFilename: "appendonly.aof", AppendDirname: "appendonlydir", AppendFsync: FsyncAlways, AOFLoadTruncated: true, AOFUseRDBPreamble: true}
+}
+
+func newTestAOF(cfg *config.Config, databases *store.Databases) *AOF {
+	return NewAOF(cfg, databases, func(fn func()) { fn() })
+}
+
+func bulkStrings(args ...string) []resp.Value {
+	items := make([]resp.Value, len(args))
+	for i, arg := range args {
+		items[i] = resp.NewBulkString(arg)
+	}
+	return items
+}
+
+// replayed loads the append only file and returns each command with the
+// database it was applied to.
+func replayed(t *testing.T, aof *AOF) []string {
+	t.Helper()
+
+	var commands []string
+	_, err := aof.Load(func(s *session.Session, command resp.Value) resp.Value {
+		items := command.(*resp.Array).Items()
+		if strings.EqualFold(items[0].String(), "SELECT") {
+			s.DB = int(items[1].String()[0] - '0') // single digit databases in these tests
+			return resp.NewSimpleString("OK")
+		}
+		commands = append(commands, fmt.Sprintf("%d: %s", s.DB, command.String()))
+		return resp.NewSimpleString("OK")
+	})
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	return commands
+}
+
+func TestAOFAppendAndLoad(t *testing.T) {
+	cfg := aofConfig(t)
+	aof := newTestAOF(cfg, store.NewDatabases(16))
+
+	aof.Append(0, bulkStrings("SET", "ignored", "before open"))
+	if err := aof.Open(); err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	aof.Append(0, bulkStrings("SET", "foo", "bar"))
+	aof.Append(0, bulkStrings("RPUSH", "list", "a"))
+	aof.Append(2, bulkStrings("SET", "foo", "two"))
+	aof.Close()
+
+	commands := replayed(t, newTestAOF(cfg, store.NewDatabases(16)))
+
+	expected := []string{"0: SET foo bar", "0: RPUSH list a", "2: SET foo two"}
+	if strings.Join(commands, "|") != strings.Join(expected, "|") {
+		t.Errorf("Expected %v, got %v", expected, commands)
+	}
+}
+
+func TestAOFMakesExpiryAbsolute(t *testing.T) {
+	cfg := aofConfig(t)
+	aof := newTestAOF(cfg, store.NewDatabases(16))
+	aof.Open()
+	aof.Append(0, bulkStrings("SET", "foo", "bar", "px", "1000"))
+	aof.Close()
+
+	data, _ := os.ReadFile(filepath.Join(cfg.Dir, cfg.AppendDirname, "appendonly.aof.1.incr.aof"))
+	if !strings.Contains(string(data), "PXAT") || strings.Contains(string(data), "px") {
+		t.Errorf("Expected the relative expiry to be rewritten, got %q", data)
+	}
+}
+
+func TestAOFLoadTruncated(t *testing.T) {
+	cfg := aofConfig(t)
+	legacy := filepath.Join(cfg.Dir, cfg.AppendFilename)
+
+	complete := "*3\r\n$3\r\nSET\r\n$3\r\nfoo\r\n$3\r\nbar\r\n"
+	os.WriteFile(legacy, []byte(complete+"*3\r\n$3\r\nSET\r\n$3\r\nba"), 0o644)
+
+	cfg.AOFLoadTruncated = false
+	if _, err := newTestAOF(cfg, store.NewDatabases(16)).Load(func(*session.Session, resp.Value) resp.Value { return nil }); err == nil {
+		t.Error("Expected an error when truncated files are not allowed")
+	}
+
+	cfg.AOFLoadTruncated = true
+	commands := replayed(t, newTestAOF(cfg, store.NewDatabases(16)))
+	if len(commands) != 1 {
+		t.Errorf("Expected the complete command to be replayed, got %v", commands)
+	}
+
+	// The legacy file is moved into the directory as the base
+	data, _ := os.ReadFile(filepath.Join(cfg.Dir, cfg.AppendDirname, cfg.AppendFilename))
+	if string(data) != complete {
+		t.Errorf("Expected the file to be truncated to the last complete command, got %q", data)
+	}
+}
+
+func TestAOFLoadMissingFile(t *testing.T) {
+	commands, err := newTestAOF(aofConfig(t), store.NewDatabases(16)).Load(nil)
+	if err != nil || commands != 0 {
+		t.Errorf("Expected a missing file to load nothing, got %d commands, %v", commands, err)
+	}
+}
+
+func TestAOFOpenWritesBaseAndManifest(t *testing.T) {
+	cfg := aofConfig(t)
+	databases := store.NewDatabases(16)
+	databases.DB(3).Set("foo", []byte("bar"))
+
+	aof := newTestAOF(cfg, databases)
+	if err := aof.Open(); err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	aof.Append(0, bulkStrings("SET", "baz", "qux"))
+	aof.Close()
+
+	data, err := os.ReadFile(filepath.Join(cfg.Dir, cfg.AppendDirname, "appendonly.aof.manifest"))
+	if err != nil {
+		t.Fatalf("Failed to read manifest: %v", err)
+	}
+	expected := "file appendonly.aof.1.base.rdb seq 1 type b\nfile appendonly.aof.1.incr.aof seq 1 type i\n"
+	if string(data) != expected {
+		t.Errorf("Expected manifest %q, got %q", expected, data)
+	}
+
+	reloaded := store.NewDatabases(16)
+	commands := replayed(t, newTestAOF(cfg, reloaded))
+	if value, _ := reloaded.DB(3).Get("foo"); !reflect.DeepEqual(value, []byte("bar")) {
+		t.Errorf("Expected the base file to restore foo in database 3, got %v", value)
+	}
+	if strings.Join(commands, "|") != "0: SET baz qux" {
+		t.Errorf("Expected the incremental file to be replayed, got %v", commands)
+	}
+}
+
+func TestAOFRewriteKeepsConcurrentWrites(t *testing.T) {
+	for _, preamble := range []bool{true, false} {
+		cfg := aofConfig(t)
+		cfg.AOFUseRDBPreamble = preamble
+		databases := store.NewDatabases(16)
+
+		aof := newTestAOF(cfg, databases)
+		if err := aof.Open(); err != nil {
+			t.Fatalf("Open failed: %v", err)
+		}
+		for _, value := range []string{"a", "b", "c"} {
+			databases.DB(0).Set("foo", []byte(value))
+			aof.Append(0, bulkStrings("SET", "foo", value))
+		}
+
+		// A write logged between the snapshot and the new base file
+		// lands in the new incremental file
+		snapshot, err := aof.switchIncremental()
+		if err != nil {
+			t.Fatalf("switchIncremental failed: %v", err)
+		}
+		databases.DB(1).Set("late", []byte("x"))
+		aof.Append(1, bulkStrings("SET", "late", "x"))
+		aof.finishRewrite(aof.writeBase(snapshot))
+		aof.Close()
+
+		if err := aof.LastRewriteStatus(); err != nil {
+			t.Fatalf("preamble=%v: rewrite failed: %v", preamble, err)
+		}
+
+		entries, _ := os.ReadDir(filepath.Join(cfg.Dir, cfg.AppendDirname))
+		if len(entries) != 3 {
+			t.Errorf("preamble=%v: expected a base, an incremental file and the manifest, got %d files", preamble, len(entries))
+		}
+
+		reloaded := store.NewDatabases(16)
+		aof = newTestAOF(cfg, reloaded)
+		commands := replayed(t, aof)
+		expected := []string{"1: SET late x"}
+		if !preamble {
+			expected = []string{"0: SET foo c", "1: SET late x"}
+		}
+		if strings.Join(commands, "|") != strings.Join(expected, "|") {
+			t.Errorf("preamble=%v: expected %v, got %v", preamble, expected, commands)
+		}
+		if preamble {
+			if value, _ := reloaded.DB(0).Get("foo"); !reflect.DeepEqual(value, []byte("c")) {
+				t.Errorf("Expected foo to be restored from the base file, got %v", value)
+			}
+		}
+	}
+}
+
+func TestAOFRewriteWithoutPreamble(t *testing.T) {
+	cfg := aofConfig(t)
+	cfg.AOFUseRDBPreamble = false
+	databases := store.NewDatabases(16)
+
+	set := store.NewSet()
+	set.Add("a", "b")
+	hash := store.NewHash()
+	hash.Set("field", "value")
+	sortedSet := store.NewSortedSet()
+	sortedSet.Add("member", 1.5)
+	list := store.NewList()
+	list.Append(bulkStrings("x", "y"))
+	databases.DB(0).Set("set", set)
+	databases.DB(0).Set("hash", hash)
+	databases.DB(1).Set("zset", sortedSet)
+	databases.DB(1).SetWithExpiry("list", list, time.Hour)
+
+	aof := newTestAOF(cfg, databases)
+	if err := aof.Open(); err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	aof.Close()
+
+	// Values without write commands, and lists with an expiry, are logged
+	// as RESTORE key timestamp payload ABSTTL REPLACE
+	restored := make(map[string]any)
+	_, err := newTestAOF(cfg, store.NewDatabases(16)).Load(func(s *session.Session, command resp.Value) resp.Value {
+		items := command.(*resp.Array).Items()
+		if !strings.EqualFold(items[0].String(), "RESTORE") {
+			return resp.NewSimpleString("OK")
+		}
+		if len(items) != 6 || items[4].String() != "ABSTTL" || items[5].String() != "REPLACE" {
+			t.Errorf("Unexpected command %q", command.String())
+		}
+		if expiresAt, _ := strconv.ParseInt(items[2].String(), 10, 64); (items[1].String() == "list") != (expiresAt > time.Now().UnixMilli()) {
+			t.Errorf("Unexpected expiry %d for %s", expiresAt, items[1].String())
+		}
+		value, err := store.Restore(resp.Bytes(items[3]))
+		if err != nil {
+			t.Errorf("Failed to restore %s: %v", items[1].String(), err)
+		}
+		restored[items[1].String()] = value
+		return resp.NewSimpleString("OK")
+	})
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	if len(restored) != 4 {
+		t.Fatalf("Expected 4 keys to be restored, got %v", restored)
+	}
+	if members := restored["set"].(*store.Set).Members(); len(members) != 2 {
+		t.Errorf("Expected 2 set members, got %v", members)
+	}
+	if fields := restored["hash"].(*store.Hash).Fields(); !reflect.DeepEqual(fields, hash.Fields()) {
+		t.Errorf("Expected %v, got %v", hash.Fields(), fields)
+	}
+	if entries := restored["zset"].(*store.SortedSet).Entries(); !reflect.DeepEqual(entries, sortedSet.Entries()) {
+		t.Errorf("Expected %v, got %v", sortedSet.Entries(), entries)
+	}
+	if size := restored["list"].(*store.List).Size(); size != 2 {
+		t.Errorf("Expected a list of 2 elements, got %d", size)
+	}
+}
+
+func TestAOFRewriteFailureBacksOff(t *testing.T) {
+	cfg := aofConfig(t)
+	cfg.AutoAOFRewritePercentage = 100
+	cfg.AutoAOFRewriteMinSize = 64
+	aof := newTestAOF(cfg, store.NewDatabases(16))
+	aof.currentSize, aof.baseSize = 200, 100
+
+	for failures, expected := range []time.Duration{time.Minute, 2 * time.Minute, 4 * time.Minute} {
+		aof.finishRewrite(fmt.Errorf("failure %d", failures+1))
+		if aof.shouldRewrite() {
+			t.Fatalf("Expected no automatic rewrite right after failure %d", failures+1)
+		}
+		if delay := time.Until(aof.nextAutoRewriteAt); delay > expected || delay < expected-time.Second {
+			t.Errorf("Expected a delay of %v after failure %d, got %v", expected, failures+1, delay)
+		}
+	}
+
+	aof.finishRewrite(nil)
+	if !aof.shouldRewrite() {
+		t.Error("Expected automatic rewrites to resume after a successful rewrite")
+	}
+}
+
+func TestAOFRewriteInProgress(t *testing.T) {
+	aof := newTestAOF(aofConfig(t), store.NewDatabases(16))
+	aof.rewriting = true
+
+	if err := aof.Rewrite(); err != ErrRewriteInProgress {
+		t.Errorf("Expected ErrRewriteInProgress, got %v", err)
+	}
+}
+
+func TestAOFShouldRewrite(t *testing.T) {
+	tests := []struct {
+		current, base int64
+		expected      bool
+	}{
+		{current: 50, base: 10, expected: false},   // below the minimum size
+		{current: 150, base: 100, expected: false}, // grew by 50%
+		{current: 200, base: 100, expected: true},  // grew by 100%
+		{current: 100, base: 0, expected: true},
+	}
+
+	cfg := aofConfig(t)
+	cfg.AutoAOFRewritePercentage = 100
+	cfg.AutoAOFRewriteMinSize = 64
+	for _, test := range tests {
+		aof := newTestAOF(cfg, store.NewDatabases(16))
+		aof.currentSize, aof.baseSize = test.current, test.base
+		if aof.shouldRewrite() != test.expected {
+			t.Errorf("current=%d base=%d: expected %v", test.current, test.base, test.expected)
+		}
+	}
+
+	cfg.AutoAOFRewritePercentage = 0
+	aof := newTestAOF(cfg, store.NewDatabases(16))
+	aof.currentSize = 1 << 20
+	if aof.shouldRewrite() {
+		t.Error("Expected automatic rewrites to be disabled by a zero percentage")
+	}
+}
+
+func TestAbsoluteExpiryForRestore(t *testing.T) {
+	rewritten := absoluteExpiry(bulkStrings("RESTORE", "foo", "1000", "payload", "REPLACE"))
+	if len(rewritten) != 6 || rewritten[5].String() != "ABSTTL" {
+		t.Fatalf("Expected ABSTTL to be added, got %v", rewritten)
+	}
+	timestamp, _ := strconv.ParseInt(rewritten[2].String(), 10, 64)
+	if timestamp < time.Now().UnixMilli() {
+		t.Errorf("Expected an absolute expiry, got %d", timestamp)
+	}
+
+	for _, command := range [][]resp.Value{
+		bulkStrings("RESTORE", "foo", "0", "payload"),
+		bulkStrings("RESTORE", "foo", "1000", "payload", "ABSTTL"),
+	} {
+		if rewritten := absoluteExpiry(command); len(rewritten) != len(command) || rewritten[2].String() != command[2].String() {
+			t.Errorf("Expected %v to be logged as is, got %v", command, rewritten)
+		}
+	}
+}
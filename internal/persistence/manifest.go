@@ -0,0 +1,164 @@
+package persistence
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// AOF file types recorded in the manifest
+const (
+	aofBase        = "b"
+	aofIncremental = "i"
+)
+
+// aofFile is one part of a multi-part append only file.
+type aofFile struct {
+	name string
+	seq  int
+	kind string
+}
+
+// manifest lists the parts of a multi-part append only file: at most one
+// base file holding the dataset at the last rewrite, followed by the
+// incremental files logging the writes since, in the order they are loaded.
+type manifest struct {
+	base         *aofFile
+	incrementals []aofFile
+}
+
+// readManifest parses the manifest at path, returning nil if it does not
+// exist. Each line has the form "file <name> seq <n> type <b|i>".
+func readManifest(path string) (*manifest, error) {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	m := &manifest{}
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		part, err := parseManifestLine(text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid manifest line %d: %w", line, err)
+		}
+
+		switch part.kind {
+		case aofBase:
+			if m.base != nil {
+				return nil, fmt.Errorf("invalid manifest line %d: more than one base file", line)
+			}
+			m.base = &part
+		case aofIncremental:
+			m.incrementals = append(m.incrementals, part)
+		}
+	}
+
+	return m, scanner.Err()
+}
+
+func parseManifestLine(line string) (aofFile, error) {
+	fields := strings.Fields(line)
+	if len(fields)%2 != 0 {
+		return aofFile{}, fmt.Errorf("expected key value pairs, got %q", line)
+	}
+
+	var part aofFile
+	for i := 0; i < len(fields); i += 2 {
+		switch fields[i] {
+		case "file":
+			part.name = fields[i+1]
+		case "seq":
+			seq, err := strconv.Atoi(fields[i+1])
+			if err != nil {
+				return aofFile{}, fmt.Errorf("invalid seq %q", fields[i+1])
+			}
+			part.seq = seq
+		case "type":
+			part.kind = fields[i+1]
+		}
+	}
+
+	if part.name == "" || filepath.Base(part.name) != part.name {
+		return aofFile{}, fmt.Errorf("invalid file name %q", part.name)
+	}
+	// History files are left behind by an interrupted cleanup and not loaded
+	if part.kind != aofBase && part.kind != aofIncremental && part.kind != "h" {
+		return aofFile{}, fmt.Errorf("unknown file type %q", part.kind)
+	}
+	return part, nil
+}
+
+// files returns the parts in load order.
+func (m *manifest) files() []aofFile {
+	var files []aofFile
+	if m.base != nil {
+		files = append(files, *m.base)
+	}
+	return append(files, m.incrementals...)
+}
+
+func (m *manifest) nextBaseSeq() int {
+	if m.base == nil {
+		return 1
+	}
+	return m.base.seq + 1
+}
+
+func (m *manifest) nextIncrementalSeq() int {
+	seq := 0
+	for _, file := range m.incrementals {
+		seq = max(seq, file.seq)
+	}
+	return seq + 1
+}
+
+func (m *manifest) encode() []byte {
+	var builder strings.Builder
+	for _, file := range m.files() {
+		fmt.Fprintf(&builder, "file %s seq %d type %s\n", file.name, file.seq, file.kind)
+	}
+	return []byte(builder.String())
+}
+
+// write replaces the manifest at path atomically.
+func (m *manifest) write(path string) error {
+	temp, err := os.CreateTemp(filepath.Dir(path), "temp-manifest-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp manifest: %w", err)
+	}
+	defer os.Remove(temp.Name())
+
+	if _, err := temp.Write(m.encode()); err != nil {
+		temp.Close()
+		return err
+	}
+	if err := temp.Chmod(0o644); err != nil {
+		temp.Close()
+		return err
+	}
+	if err := temp.Sync(); err != nil {
+		temp.Close()
+		return err
+	}
+	if err := temp.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Rename(temp.Name(), path); err != nil {
+		return fmt.Errorf("failed to rename manifest: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,253 @@
+package persistence
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/md-talim/codecrafters-redis-go/internal/config"
+	"github.com/md-talim/codecrafters-redis-go/internal/store"
+)
+
+var ErrSaveInProgress = errors.New("background save already in progress")
+
+// bgsaveRetryDelay is how long save points wait after a failed background
+// save before trying again.
+const bgsaveRetryDelay = 5 * time.Second
+
+// Saver writes snapshots of the databases to the RDB file named by the
+// config.
+type Saver struct {
+	databases *store.Databases
+	config    *config.Config
+
+	// exclusive runs a function while no write command executes
+	exclusive func(func())
+
+	lastSave         time.Time
+	lastBgsaveStatus error
+	lastBgsaveTry    time.Time
+	bgsaveInProgress bool
+	mu               sync.RWMutex
+
+	// dirtyAtLastSave is the dirty counter of the databases when the last
+	// saved snapshot was taken
+	dirtyAtLastSave int64
+
+	// writeMu prevents two saves from writing the file at the same time
+	writeMu sync.Mutex
+
+	closer chan struct{}
+}
+
+// NewSaver returns a saver for the databases. exclusive must run its
+// argument while no write command executes, so a snapshot of every database
+// is taken at the same point.
+func NewSaver(databases *store.Databases, config *config.Config, exclusive func(func())) *Saver {
+	return &Saver{
+		databases: databases,
+		config:    config,
+		exclusive: exclusive,
+		lastSave:  time.Now(),
+		closer:    make(chan struct{}),
+	}
+}
+
+// Save writes the dataset to disk, blocking until it is done.
+func (s *Saver) Save() error {
+	s.mu.RLock()
+	inProgress := s.bgsaveInProgress
+	s.mu.RUnlock()
+	if inProgress {
+		return ErrSaveInProgress
+	}
+
+	snapshot, dirty := s.snapshot()
+	if err := s.write(snapshot); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.lastSave = time.Now()
+	s.dirtyAtLastSave = dirty
+	s.mu.Unlock()
+	return nil
+}
+
+// BackgroundSave takes a snapshot of the dataset and writes it to disk in
+// the background, so clients are only blocked while the snapshot is copied.
+func (s *Saver) BackgroundSave() error {
+	s.mu.Lock()
+	if s.bgsaveInProgress {
+		s.mu.Unlock()
+		return ErrSaveInProgress
+	}
+	s.bgsaveInProgress = true
+	s.lastBgsaveTry = time.Now()
+	s.mu.Unlock()
+
+	snapshot, dirty := s.snapshot()
+
+	go func() {
+		err := s.write(snapshot)
+		if err != nil {
+			fmt.Printf("Background saving error: %v\n", err)
+		}
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.bgsaveInProgress = false
+		s.lastBgsaveStatus = err
+		if err == nil {
+			s.lastSave = time.Now()
+			s.dirtyAtLastSave = dirty
+		}
+	}()
+
+	return nil
+}
+
+// Start evaluates the save points every second, saving in the background
+// when one of them matches, until Close is called.
+func (s *Saver) Start() {
+	go func() {
+		ticker := time.NewTicker(time.Second)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case now := <-ticker.C:
+				if !s.shouldSave(now) {
+					continue
+				}
+				if err := s.BackgroundSave(); err == nil {
+					fmt.Println("Save point reached, saving in the background")
+				}
+			case <-s.closer:
+				return
+			}
+		}
+	}()
+}
+
+// shouldSave reports whether a save point matches at now. After a failed
+// background save, the next attempt waits for bgsaveRetryDelay.
+func (s *Saver) shouldSave(now time.Time) bool {
+	changes := s.ChangesSinceLastSave()
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.bgsaveInProgress {
+		return false
+	}
+	if s.lastBgsaveStatus != nil && now.Sub(s.lastBgsaveTry) < bgsaveRetryDelay {
+		return false
+	}
+
+	elapsed := now.Sub(s.lastSave)
+	for _, point := range s.config.SavePoints {
+		if changes >= point.Changes && elapsed >= time.Duration(point.Seconds)*time.Second {
+			return true
+		}
+	}
+	return false
+}
+
+// Close stops evaluating the save points.
+func (s *Saver) Close() {
+	close(s.closer)
+}
+
+// ChangesSinceLastSave returns the number of changes made to the dataset
+// since the last successful save.
+func (s *Saver) ChangesSinceLastSave() int64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.databases.Dirty() - s.dirtyAtLastSave
+}
+
+// ResetChanges treats the current dataset as saved, such as after it was
+// loaded from disk.
+func (s *Saver) ResetChanges() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.dirtyAtLastSave = s.databases.Dirty()
+}
+
+// LastSave returns the time of the last successful save.
+func (s *Saver) LastSave() time.Time {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.lastSave
+}
+
+func (s *Saver) BackgroundSaveInProgress() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.bgsaveInProgress
+}
+
+// LastBackgroundSaveStatus returns the error of the last background save,
+// or nil if it succeeded.
+func (s *Saver) LastBackgroundSaveStatus() error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.lastBgsaveStatus
+}
+
+// snapshot copies every database, with the dirty counter it matches, while
+// no write command executes. A write between two databases, such as a MOVE,
+// would otherwise be saved half done.
+func (s *Saver) snapshot() ([]map[string]store.Item, int64) {
+	var snapshot []map[string]store.Item
+	var dirty int64
+	s.exclusive(func() {
+		dirty = s.databases.Dirty()
+		snapshot = s.databases.Snapshot()
+	})
+	return snapshot, dirty
+}
+
+// write stores the snapshot in a temporary file and renames it over the RDB
+// file, so a crash never leaves a partially written dump behind.
+func (s *Saver) write(snapshot []map[string]store.Item) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	path := filepath.Join(s.config.Dir, s.config.DBFilename)
+
+	file, err := os.CreateTemp(s.config.Dir, fmt.Sprintf("temp-%d-*.rdb", os.Getpid()))
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer os.Remove(file.Name())
+
+	if err := store.WriteSnapshot(snapshot, file); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+
+	if err := file.Chmod(0o644); err != nil {
+		file.Close()
+		return err
+	}
+
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to sync snapshot: %w", err)
+	}
+
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Rename(file.Name(), path); err != nil {
+		return fmt.Errorf("failed to rename snapshot: %w", err)
+	}
+
+	return nil
+}
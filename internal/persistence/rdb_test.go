@@ -0,0 +1,191 @@
+package persistence
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/md-talim/codecrafters-redis-go/internal/config"
+	"github.com/md-talim/codecrafters-redis-go/internal/store"
+)
+
+func newTestSaver(databases *store.Databases, cfg *config.Config) *Saver {
+	return NewSaver(databases, cfg, func(fn func()) { fn() })
+}
+
+func TestSave(t *testing.T) {
+	cfg := &config.Config{Dir: t.TempDir(), DBFilename: "dump.rdb"}
+	databases := store.NewDatabases(1)
+	databases.DB(0).Set("foo", []byte("bar"))
+
+	saver := newTestSaver(databases, cfg)
+	before := saver.LastSave()
+
+	if err := saver.Save(); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	if saver.LastSave().Before(before) {
+		t.Error("Expected LastSave to advance")
+	}
+
+	reloaded := store.NewDatabases(16)
+	defer reloaded.Close()
+	if _, err := store.Load(reloaded, cfg, nil); err != nil {
+		t.Fatalf("Failed to reload: %v", err)
+	}
+	if value, exists := reloaded.DB(0).Get("foo"); !exists || !reflect.DeepEqual(value, []byte("bar")) {
+		t.Errorf("Expected foo=bar after reload, got %v", value)
+	}
+
+	entries, _ := os.ReadDir(cfg.Dir)
+	if len(entries) != 1 {
+		t.Errorf("Expected only the dump file to remain, got %d entries", len(entries))
+	}
+}
+
+func TestSaveSnapshotsDatabasesTogether(t *testing.T) {
+	cfg := &config.Config{Dir: t.TempDir(), DBFilename: "dump.rdb"}
+	databases := store.NewDatabases(2)
+	databases.DB(0).Set("foo", []byte("bar"))
+
+	// A MOVE right after the exclusive section must not reach the file,
+	// neither half of it
+	sections := 0
+	saver := NewSaver(databases, cfg, func(fn func()) {
+		sections++
+		fn()
+		databases.Move("foo", 0, 1)
+	})
+	if err := saver.Save(); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if sections != 1 {
+		t.Errorf("Expected the snapshot to be taken in 1 exclusive section, got %d", sections)
+	}
+
+	reloaded := store.NewDatabases(2)
+	defer reloaded.Close()
+	if _, err := store.Load(reloaded, cfg, nil); err != nil {
+		t.Fatalf("Failed to reload: %v", err)
+	}
+	_, inFirst := reloaded.DB(0).Get("foo")
+	_, inSecond := reloaded.DB(1).Get("foo")
+	if !inFirst || inSecond {
+		t.Errorf("Expected foo only in database 0, got %v in 0 and %v in 1", inFirst, inSecond)
+	}
+}
+
+func TestBackgroundSave(t *testing.T) {
+	cfg := &config.Config{Dir: t.TempDir(), DBFilename: "dump.rdb"}
+	databases := store.NewDatabases(1)
+	databases.DB(0).Set("foo", []byte("bar"))
+
+	saver := newTestSaver(databases, cfg)
+	if err := saver.BackgroundSave(); err != nil {
+		t.Fatalf("BackgroundSave failed: %v", err)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for saver.BackgroundSaveInProgress() && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+
+	if saver.LastBackgroundSaveStatus() != nil {
+		t.Fatalf("Background save failed: %v", saver.LastBackgroundSaveStatus())
+	}
+
+	if _, err := os.Stat(filepath.Join(cfg.Dir, "dump.rdb")); err != nil {
+		t.Errorf("Expected dump file to exist: %v", err)
+	}
+}
+
+func TestBackgroundSaveAlreadyInProgress(t *testing.T) {
+	cfg := &config.Config{Dir: t.TempDir(), DBFilename: "dump.rdb"}
+	saver := newTestSaver(store.NewDatabases(1), cfg)
+	saver.bgsaveInProgress = true
+
+	if err := saver.BackgroundSave(); !errors.Is(err, ErrSaveInProgress) {
+		t.Errorf("Expected ErrSaveInProgress, got %v", err)
+	}
+
+	if err := saver.Save(); !errors.Is(err, ErrSaveInProgress) {
+		t.Errorf("Expected ErrSaveInProgress, got %v", err)
+	}
+}
+
+func TestChangesSinceLastSave(t *testing.T) {
+	cfg := &config.Config{Dir: t.TempDir(), DBFilename: "dump.rdb"}
+	databases := store.NewDatabases(2)
+	saver := newTestSaver(databases, cfg)
+
+	databases.DB(0).Set("foo", []byte("bar"))
+	databases.DB(1).Set("baz", []byte("qux"))
+	databases.DB(1).Delete("missing")
+	if changes := saver.ChangesSinceLastSave(); changes != 2 {
+		t.Errorf("Expected 2 changes, got %d", changes)
+	}
+
+	if err := saver.Save(); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if changes := saver.ChangesSinceLastSave(); changes != 0 {
+		t.Errorf("Expected no changes after saving, got %d", changes)
+	}
+
+	databases.DB(0).Delete("foo")
+	if changes := saver.ChangesSinceLastSave(); changes != 1 {
+		t.Errorf("Expected 1 change, got %d", changes)
+	}
+	saver.ResetChanges()
+	if changes := saver.ChangesSinceLastSave(); changes != 0 {
+		t.Errorf("Expected no changes after reset, got %d", changes)
+	}
+}
+
+func TestShouldSave(t *testing.T) {
+	cfg := &config.Config{
+		Dir:        t.TempDir(),
+		DBFilename: "dump.rdb",
+		SavePoints: []config.SavePoint{{Seconds: 900, Changes: 1}, {Seconds: 300, Changes: 10}},
+	}
+	databases := store.NewDatabases(1)
+	saver := newTestSaver(databases, cfg)
+	start := saver.LastSave()
+
+	tests := []struct {
+		changes  int
+		elapsed  time.Duration
+		expected bool
+	}{
+		{changes: 0, elapsed: time.Hour, expected: false},
+		{changes: 1, elapsed: 899 * time.Second, expected: false},
+		{changes: 1, elapsed: 900 * time.Second, expected: true},
+		{changes: 9, elapsed: 600 * time.Second, expected: false},
+		{changes: 10, elapsed: 300 * time.Second, expected: true},
+	}
+
+	for _, test := range tests {
+		saver.ResetChanges()
+		for i := range test.changes {
+			databases.DB(0).Set(fmt.Sprintf("key%d", i), []byte("value"))
+		}
+		if saver.shouldSave(start.Add(test.elapsed)) != test.expected {
+			t.Errorf("%d changes after %v: expected %v", test.changes, test.elapsed, test.expected)
+		}
+	}
+
+	// A failed background save is retried only after a delay
+	saver.lastBgsaveStatus = errors.New("disk full")
+	saver.lastBgsaveTry = start.Add(time.Hour)
+	if saver.shouldSave(start.Add(time.Hour + time.Second)) {
+		t.Error("Expected no save right after a failure")
+	}
+	if !saver.shouldSave(start.Add(time.Hour + bgsaveRetryDelay)) {
+		t.Error("Expected a retry after the delay")
+	}
+}
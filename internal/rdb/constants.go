@@ -2,17 +2,55 @@ package rdb
 
 // RDB Op Codes as defined in the RDB file format specification
 const (
-	OpEOF          = 0xFF // OpEOF marks the end of the RDB file
-	OpSelectDB     = 0xFE // OpSelectDB indicates a database selector
-	OpExpireTime   = 0xFD // OpExpireTime indicates expire time in seconds
-	OpExpireTimeMS = 0xFC // OpExpireTimeMS indicates expire time in milliseconds
-	OpResizeDB     = 0xFB // OpResizeDB indicates hash table sizes for main keyspace and expres
-	OpAux          = 0xFA // OpAux indicates auxiliary fields (arbitrary key-value settings)
+	OpEOF           = 0xFF // OpEOF marks the end of the RDB file
+	OpSelectDB      = 0xFE // OpSelectDB indicates a database selector
+	OpExpireTime    = 0xFD // OpExpireTime indicates expire time in seconds
+	OpExpireTimeMS  = 0xFC // OpExpireTimeMS indicates expire time in milliseconds
+	OpResizeDB      = 0xFB // OpResizeDB indicates hash table sizes for main keyspace and expres
+	OpAux           = 0xFA // OpAux indicates auxiliary fields (arbitrary key-value settings)
+	OpFreq          = 0xF9 // OpFreq holds the LFU counter of the next key
+	OpIdle          = 0xF8 // OpIdle holds the LRU idle time of the next key
+	OpModuleAux     = 0xF7 // OpModuleAux holds data a module stores outside of keys
+	OpFunctionPreGA = 0xF6 // OpFunctionPreGA holds a function in the format of Redis 7.0 release candidates
+	OpFunction2     = 0xF5 // OpFunction2 holds the source code of a function library
+	OpSlotInfo      = 0xF4 // OpSlotInfo holds the size of a cluster slot
+)
+
+// Value opcodes in module data
+const (
+	ModuleOpcodeEOF    = 0
+	ModuleOpcodeSint   = 1
+	ModuleOpcodeUint   = 2
+	ModuleOpcodeFloat  = 3
+	ModuleOpcodeDouble = 4
+	ModuleOpcodeString = 5
 )
 
 // Value type encodings
 const (
 	ValueTypeString = 0x00
+	ValueTypeList   = 0x01
+	ValueTypeSet    = 0x02
+	ValueTypeZSet   = 0x03
+	ValueTypeHash   = 0x04
+	ValueTypeZSet2  = 0x05
+
+	ValueTypeHashZipmap     = 0x09
+	ValueTypeListZiplist    = 0x0A
+	ValueTypeSetIntset      = 0x0B
+	ValueTypeZSetZiplist    = 0x0C
+	ValueTypeHashZiplist    = 0x0D
+	ValueTypeListQuicklist  = 0x0E
+	ValueTypeHashListpack   = 0x10
+	ValueTypeZSetListpack   = 0x11
+	ValueTypeListQuicklist2 = 0x12
+	ValueTypeSetListpack    = 0x14
+)
+
+// Quicklist 2 node containers
+const (
+	QuicklistNodePlain  = 0x01 // The node holds a single element as is
+	QuicklistNodePacked = 0x02 // The node holds a listpack of elements
 )
 
 // String encoding special values
@@ -20,18 +58,27 @@ const (
 	StringEnc8BitInt  = 0xC0 // Indicates an 8-bit integer encoding
 	StringEnc16BitInt = 0xC1 // Indicates an 16-bit integer encoding
 	StringEnc32BitInt = 0xC2 // Indicates an 32-bit integer encoding
-	StringEncLZF      = 0xC3 // Indicates LZF compression (not implemented)
+	StringEncLZF      = 0xC3 // Indicates LZF compression
 )
 
 // Size encoding masks
 const (
 	SizeEncodingMask = 0xC0 // Used to extract the 2 bits for size encoding type
 	SizeValueMask    = 0x3F // Used to extract the remaining 6 bits for size value
+	Size32Bit        = 0x80 // A 32-bit big endian size follows
+	Size64Bit        = 0x81 // A 64-bit big endian size follows
 )
 
-// RDB file header
+// RDB file header. Files are written with RDBVersion and read from any
+// version between MinRDBVersion and MaxRDBVersion.
 const (
 	RDBMagicString = "REDIS"
 	RDBVersion     = "0011"
 	RDBHeader      = RDBMagicString + RDBVersion
+
+	MinRDBVersion = 1
+	MaxRDBVersion = 12
+
+	// ChecksumRDBVersion is the first version ending with a CRC64
+	ChecksumRDBVersion = 5
 )
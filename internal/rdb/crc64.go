@@ -0,0 +1,15 @@
+package rdb
+
+import "hash/crc64"
+
+// jonesPolynomial is the reflected CRC-64-Jones polynomial Redis uses to
+// checksum RDB files.
+const jonesPolynomial = 0x95ac9329ac4bc9b5
+
+var jonesTable = crc64.MakeTable(jonesPolynomial)
+
+// CRC64 extends the checksum crc with data. Unlike hash/crc64, Redis applies
+// no initial or final inversion, so those are undone here.
+func CRC64(crc uint64, data []byte) uint64 {
+	return ^crc64.Update(^crc, jonesTable, data)
+}
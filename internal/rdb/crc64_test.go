@@ -0,0 +1,18 @@
+package rdb
+
+import "testing"
+
+func TestCRC64(t *testing.T) {
+	// Check value from the Redis crc64 test suite
+	checksum := CRC64(0, []byte("123456789"))
+	if checksum != 0xe9c6d914c4b8d9ca {
+		t.Errorf("Expected 0xe9c6d914c4b8d9ca, got 0x%x", checksum)
+	}
+}
+
+func TestCRC64Incremental(t *testing.T) {
+	checksum := CRC64(CRC64(0, []byte("1234")), []byte("56789"))
+	if checksum != CRC64(0, []byte("123456789")) {
+		t.Errorf("Expected incremental checksum to match, got 0x%x", checksum)
+	}
+}
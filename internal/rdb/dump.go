@@ -0,0 +1,94 @@
+package rdb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// ErrBadDumpPayload is returned for a payload with an unknown RDB version or
+// a checksum that does not match.
+var ErrBadDumpPayload = errors.New("DUMP payload version or checksum are wrong")
+
+// dumpFooterSize is the RDB version and CRC64 that end a DUMP payload.
+const dumpFooterSize = 2 + 8
+
+// rdbVersion is RDBVersion as a number, as stored in DUMP payloads.
+var rdbVersion, _ = strconv.Atoi(RDBVersion)
+
+// Dump serializes a single value in the format of the DUMP command: the
+// value type and its RDB encoding, followed by the RDB version as 2 bytes
+// and the CRC64 of everything before it, both little endian. Value holds a
+// string, List, Set, Hash or SortedSet.
+func Dump(value any) ([]byte, error) {
+	var buffer bytes.Buffer
+	w := NewWriter(&buffer)
+
+	var err error
+	switch value := value.(type) {
+	case string:
+		if err = w.writer.WriteByte(ValueTypeString); err == nil {
+			err = w.writeString(value)
+		}
+	case List:
+		if err = w.writer.WriteByte(ValueTypeList); err == nil {
+			err = w.writeStrings(value)
+		}
+	case Set:
+		if err = w.writer.WriteByte(ValueTypeSet); err == nil {
+			err = w.writeStrings(value)
+		}
+	case Hash:
+		if err = w.writer.WriteByte(ValueTypeHash); err == nil {
+			err = w.writeHash(value)
+		}
+	case SortedSet:
+		if err = w.writer.WriteByte(ValueTypeZSet2); err == nil {
+			err = w.writeSortedSet(value)
+		}
+	default:
+		return nil, fmt.Errorf("cannot dump value of type %T", value)
+	}
+	if err == nil {
+		err = w.writer.Flush()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	payload := binary.LittleEndian.AppendUint16(buffer.Bytes(), uint16(rdbVersion))
+	return binary.LittleEndian.AppendUint64(payload, CRC64(0, payload)), nil
+}
+
+// ParseDump decodes a payload written by Dump or by a server using an RDB
+// version up to MaxRDBVersion. It returns ErrBadDumpPayload if the payload
+// was written by a newer version or is corrupt.
+func ParseDump(payload []byte) (any, error) {
+	if len(payload) < dumpFooterSize+1 {
+		return nil, ErrBadDumpPayload
+	}
+
+	body := payload[:len(payload)-8]
+	version := binary.LittleEndian.Uint16(body[len(body)-2:])
+	checksum := binary.LittleEndian.Uint64(payload[len(payload)-8:])
+	if int(version) > MaxRDBVersion || checksum != CRC64(0, body) {
+		return nil, ErrBadDumpPayload
+	}
+
+	reader := NewReaderFrom(bytes.NewReader(body[:len(body)-2]))
+	valueType, err := reader.readByte()
+	if err != nil {
+		return nil, err
+	}
+	value, err := reader.readValue(valueType)
+	if err != nil {
+		return nil, err
+	}
+	if reader.file.offset != int64(len(body)-2) {
+		return nil, fmt.Errorf("%d bytes left after the value", int64(len(body)-2)-reader.file.offset)
+	}
+
+	return value, nil
+}
@@ -0,0 +1,88 @@
+package rdb
+
+import (
+	"encoding/binary"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDumpRoundTrip(t *testing.T) {
+	values := []any{
+		"bar",
+		"12345",
+		strings.Repeat("compressible ", 10),
+		List{"a", "-5", "300"},
+		Set{"x", "y"},
+		Hash{"field": "value"},
+		SortedSet{{Member: "one", Score: 1}, {Member: "half", Score: 0.5}},
+	}
+
+	for _, value := range values {
+		payload, err := Dump(value)
+		if err != nil {
+			t.Fatalf("Dump(%v) failed: %v", value, err)
+		}
+		parsed, err := ParseDump(payload)
+		if err != nil {
+			t.Fatalf("ParseDump(%v) failed: %v", value, err)
+		}
+		if !reflect.DeepEqual(parsed, value) {
+			t.Errorf("Expected %v, got %v", value, parsed)
+		}
+	}
+}
+
+func TestDumpFooter(t *testing.T) {
+	payload, _ := Dump("bar")
+
+	expected := "\x00\x03bar\x0b\x00"
+	if string(payload[:len(expected)]) != expected {
+		t.Errorf("Expected payload to start with %q, got %q", expected, payload)
+	}
+	if checksum := binary.LittleEndian.Uint64(payload[len(expected):]); checksum != CRC64(0, []byte(expected)) {
+		t.Errorf("Expected the CRC64 of the value and version, got 0x%016x", checksum)
+	}
+}
+
+func TestParseDumpRejectsCorruptPayloads(t *testing.T) {
+	payload, _ := Dump("bar")
+
+	corrupt := append([]byte{}, payload...)
+	corrupt[2] ^= 0xff
+
+	newer := append([]byte{}, payload[:len(payload)-10]...)
+	newer = binary.LittleEndian.AppendUint16(newer, MaxRDBVersion+1)
+	newer = binary.LittleEndian.AppendUint64(newer, CRC64(0, newer))
+
+	for name, payload := range map[string][]byte{
+		"flipped byte":  corrupt,
+		"newer version": newer,
+		"too short":     payload[:5],
+	} {
+		if _, err := ParseDump(payload); !errors.Is(err, ErrBadDumpPayload) {
+			t.Errorf("%s: expected ErrBadDumpPayload, got %v", name, err)
+		}
+	}
+
+	// An older version with a valid checksum is accepted
+	older := append([]byte{}, payload[:len(payload)-10]...)
+	older = binary.LittleEndian.AppendUint16(older, 9)
+	older = binary.LittleEndian.AppendUint64(older, CRC64(0, older))
+	if value, err := ParseDump(older); err != nil || value != "bar" {
+		t.Errorf("Expected an older payload to be accepted, got %v, %v", value, err)
+	}
+}
+
+func TestParseDumpRejectsInvalidIntset(t *testing.T) {
+	// An intset whose count reads as negative when sign extended
+	intset := []byte{0x02, 0, 0, 0, 0xFF, 0xFF, 0xFF, 0xFF}
+	payload := append([]byte{ValueTypeSetIntset, byte(len(intset))}, intset...)
+	payload = binary.LittleEndian.AppendUint16(payload, MaxRDBVersion)
+	payload = binary.LittleEndian.AppendUint64(payload, CRC64(0, payload))
+
+	if _, err := ParseDump(payload); err == nil {
+		t.Error("Expected an error for an intset with an invalid count")
+	}
+}
@@ -0,0 +1,364 @@
+package rdb
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+var errTruncated = errors.New("unexpected end of encoded value")
+
+// blob is a cursor over a compact encoding stored as a single RDB string.
+type blob struct {
+	data []byte
+	pos  int
+}
+
+func (b *blob) next(n int) ([]byte, error) {
+	if n < 0 || b.pos+n > len(b.data) {
+		return nil, errTruncated
+	}
+	bytes := b.data[b.pos : b.pos+n]
+	b.pos += n
+	return bytes, nil
+}
+
+func (b *blob) byte() (byte, error) {
+	bytes, err := b.next(1)
+	if err != nil {
+		return 0, err
+	}
+	return bytes[0], nil
+}
+
+// littleEndian reads an n byte little endian integer, sign extended.
+func (b *blob) littleEndian(n int) (int64, error) {
+	bytes, err := b.next(n)
+	if err != nil {
+		return 0, err
+	}
+
+	var value uint64
+	for i := n - 1; i >= 0; i-- {
+		value = value<<8 | uint64(bytes[i])
+	}
+	shift := 64 - 8*n
+	return int64(value<<shift) >> shift, nil
+}
+
+// decodeZiplist decodes a ziplist: a header of total bytes, tail offset and
+// entry count, followed by entries each prefixed with the length of the
+// previous one, and a 0xFF terminator.
+func decodeZiplist(data []byte) ([]string, error) {
+	b := &blob{data: data}
+	if _, err := b.next(10); err != nil {
+		return nil, fmt.Errorf("invalid ziplist header: %w", err)
+	}
+
+	var entries []string
+	for {
+		prevLen, err := b.byte()
+		if err != nil {
+			return nil, err
+		}
+		if prevLen == 0xFF {
+			return entries, nil
+		}
+		if prevLen == 0xFE {
+			if _, err := b.next(4); err != nil {
+				return nil, err
+			}
+		}
+
+		entry, err := b.ziplistEntry()
+		if err != nil {
+			return nil, fmt.Errorf("invalid ziplist entry %d: %w", len(entries), err)
+		}
+		entries = append(entries, entry)
+	}
+}
+
+func (b *blob) ziplistEntry() (string, error) {
+	header, err := b.byte()
+	if err != nil {
+		return "", err
+	}
+
+	var length int
+	switch header >> 6 {
+	case 0:
+		length = int(header & 0x3F)
+	case 1:
+		next, err := b.byte()
+		if err != nil {
+			return "", err
+		}
+		length = int(header&0x3F)<<8 | int(next)
+	case 2:
+		bytes, err := b.next(4)
+		if err != nil {
+			return "", err
+		}
+		length = int(binary.BigEndian.Uint32(bytes))
+	default:
+		return b.ziplistInteger(header)
+	}
+
+	bytes, err := b.next(length)
+	if err != nil {
+		return "", err
+	}
+	return string(bytes), nil
+}
+
+func (b *blob) ziplistInteger(header byte) (string, error) {
+	var size int
+	switch header {
+	case 0xC0:
+		size = 2
+	case 0xD0:
+		size = 4
+	case 0xE0:
+		size = 8
+	case 0xF0:
+		size = 3
+	case 0xFE:
+		size = 1
+	default:
+		// 0xF1 to 0xFD hold a value between 0 and 12 in the low bits.
+		if header >= 0xF1 && header <= 0xFD {
+			return strconv.Itoa(int(header&0x0F) - 1), nil
+		}
+		return "", fmt.Errorf("unknown ziplist encoding: 0x%02x", header)
+	}
+
+	value, err := b.littleEndian(size)
+	if err != nil {
+		return "", err
+	}
+	return strconv.FormatInt(value, 10), nil
+}
+
+// decodeListpack decodes a listpack: a header of total bytes and element
+// count, followed by entries each suffixed with their own length, and a
+// 0xFF terminator.
+func decodeListpack(data []byte) ([]string, error) {
+	b := &blob{data: data}
+	if _, err := b.next(6); err != nil {
+		return nil, fmt.Errorf("invalid listpack header: %w", err)
+	}
+
+	var entries []string
+	for {
+		start := b.pos
+		header, err := b.byte()
+		if err != nil {
+			return nil, err
+		}
+		if header == 0xFF {
+			return entries, nil
+		}
+
+		entry, err := b.listpackEntry(header)
+		if err != nil {
+			return nil, fmt.Errorf("invalid listpack entry %d: %w", len(entries), err)
+		}
+		entries = append(entries, entry)
+
+		if _, err := b.next(listpackBacklenSize(b.pos - start)); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (b *blob) listpackEntry(header byte) (string, error) {
+	var length int
+	switch {
+	case header&0x80 == 0: // 7 bit unsigned integer
+		return strconv.Itoa(int(header)), nil
+	case header&0xC0 == 0x80: // 6 bit string length
+		length = int(header & 0x3F)
+	case header&0xE0 == 0xC0: // 13 bit signed integer
+		next, err := b.byte()
+		if err != nil {
+			return "", err
+		}
+		value := int(header&0x1F)<<8 | int(next)
+		if value >= 1<<12 {
+			value -= 1 << 13
+		}
+		return strconv.Itoa(value), nil
+	case header&0xF0 == 0xE0: // 12 bit string length
+		next, err := b.byte()
+		if err != nil {
+			return "", err
+		}
+		length = int(header&0x0F)<<8 | int(next)
+	case header == 0xF0: // 32 bit string length
+		bytes, err := b.next(4)
+		if err != nil {
+			return "", err
+		}
+		length = int(binary.LittleEndian.Uint32(bytes))
+	case header >= 0xF1 && header <= 0xF4: // 16, 24, 32 or 64 bit integer
+		size := map[byte]int{0xF1: 2, 0xF2: 3, 0xF3: 4, 0xF4: 8}[header]
+		value, err := b.littleEndian(size)
+		if err != nil {
+			return "", err
+		}
+		return strconv.FormatInt(value, 10), nil
+	default:
+		return "", fmt.Errorf("unknown listpack encoding: 0x%02x", header)
+	}
+
+	bytes, err := b.next(length)
+	if err != nil {
+		return "", err
+	}
+	return string(bytes), nil
+}
+
+// listpackBacklenSize returns how many bytes encode the length of an entry,
+// seven bits per byte, with the bounds of lpEncodeBacklen in Redis.
+func listpackBacklenSize(entryLength int) int {
+	switch {
+	case entryLength <= 127:
+		return 1
+	case entryLength < 16383:
+		return 2
+	case entryLength < 2097151:
+		return 3
+	case entryLength < 268435455:
+		return 4
+	default:
+		return 5
+	}
+}
+
+// decodeIntset decodes an intset: the integer width, the element count and
+// the sorted little endian integers. The count must account for every byte
+// after the header.
+func decodeIntset(data []byte) ([]string, error) {
+	b := &blob{data: data}
+
+	width, err := b.littleEndian(4)
+	if err != nil {
+		return nil, fmt.Errorf("invalid intset header: %w", err)
+	}
+	if width != 2 && width != 4 && width != 8 {
+		return nil, fmt.Errorf("invalid intset encoding: %d", width)
+	}
+	header, err := b.next(4)
+	if err != nil {
+		return nil, fmt.Errorf("invalid intset header: %w", err)
+	}
+	count := int(binary.LittleEndian.Uint32(header))
+	if count*int(width) != len(data)-b.pos {
+		return nil, fmt.Errorf("intset of %d %d byte integers in %d bytes", count, width, len(data)-b.pos)
+	}
+
+	members := make([]string, 0, min(count, maxPreallocatedItems))
+	for range count {
+		value, err := b.littleEndian(int(width))
+		if err != nil {
+			return nil, err
+		}
+		members = append(members, strconv.FormatInt(value, 10))
+	}
+
+	return members, nil
+}
+
+// decodeZipmap decodes the pre 2.6 zipmap hash encoding.
+func decodeZipmap(data []byte) (Hash, error) {
+	b := &blob{data: data}
+	if _, err := b.byte(); err != nil {
+		return nil, fmt.Errorf("invalid zipmap header: %w", err)
+	}
+
+	hash := make(Hash)
+	for {
+		field, end, err := b.zipmapString(false)
+		if err != nil {
+			return nil, err
+		}
+		if end {
+			return hash, nil
+		}
+		value, _, err := b.zipmapString(true)
+		if err != nil {
+			return nil, err
+		}
+		hash[field] = value
+	}
+}
+
+// zipmapString reads a length prefixed zipmap string. Values carry a count
+// of trailing free bytes after the length, which are skipped.
+func (b *blob) zipmapString(value bool) (string, bool, error) {
+	first, err := b.byte()
+	if err != nil {
+		return "", false, err
+	}
+
+	length := int(first)
+	switch first {
+	case 0xFF:
+		return "", true, nil
+	case 0xFE:
+		bytes, err := b.next(4)
+		if err != nil {
+			return "", false, err
+		}
+		length = int(binary.LittleEndian.Uint32(bytes))
+	}
+
+	free := 0
+	if value {
+		f, err := b.byte()
+		if err != nil {
+			return "", false, err
+		}
+		free = int(f)
+	}
+
+	bytes, err := b.next(length)
+	if err != nil {
+		return "", false, err
+	}
+	if _, err := b.next(free); err != nil {
+		return "", false, err
+	}
+	return string(bytes), false, nil
+}
+
+// pairs converts a flat field, value sequence into a Hash.
+func pairs(entries []string) (Hash, error) {
+	if len(entries)%2 != 0 {
+		return nil, fmt.Errorf("odd number of hash entries: %d", len(entries))
+	}
+
+	hash := make(Hash, len(entries)/2)
+	for i := 0; i < len(entries); i += 2 {
+		hash[entries[i]] = entries[i+1]
+	}
+	return hash, nil
+}
+
+// scoredPairs converts a flat member, score sequence into a SortedSet.
+func scoredPairs(entries []string) (SortedSet, error) {
+	if len(entries)%2 != 0 {
+		return nil, fmt.Errorf("odd number of sorted set entries: %d", len(entries))
+	}
+
+	sortedSet := make(SortedSet, 0, len(entries)/2)
+	for i := 0; i < len(entries); i += 2 {
+		score, err := strconv.ParseFloat(entries[i+1], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid score %q: %w", entries[i+1], err)
+		}
+		sortedSet = append(sortedSet, SortedSetEntry{entries[i], score})
+	}
+	return sortedSet, nil
+}
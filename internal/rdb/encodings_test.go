@@ -0,0 +1,138 @@
+package rdb
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDecodeZiplist(t *testing.T) {
+	data := []byte{
+		0, 0, 0, 0, 0, 0, 0, 0, 0, 0, // zlbytes, zltail, zllen
+		0x00, 0x01, 'a', // 6 bit string
+		0x03, 0xF6, // immediate 5
+		0x02, 0xC0, 0xD4, 0xFE, // int16 -300
+		0x04, 0xFE, 0x80, // int8 -128
+		0x03, 0xF0, 0x00, 0x00, 0x80, // int24 -8388608
+		0x05, 0x40, 0x40, // 14 bit string length
+	}
+	data = append(data, strings.Repeat("x", 64)...)
+	data = append(data, 0xFE, 0x43, 0, 0, 0, 0xD0, 0xA0, 0x86, 0x01, 0x00) // 5 byte prevlen, int32 100000
+	data = append(data, 0xFF)
+
+	entries, err := decodeZiplist(data)
+	if err != nil {
+		t.Fatalf("decodeZiplist failed: %v", err)
+	}
+
+	expected := []string{"a", "5", "-300", "-128", "-8388608", strings.Repeat("x", 64), "100000"}
+	if !reflect.DeepEqual(entries, expected) {
+		t.Errorf("Expected %v, got %v", expected, entries)
+	}
+}
+
+func TestDecodeZiplistTruncated(t *testing.T) {
+	data := []byte{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0x00, 0x05, 'a'}
+
+	if _, err := decodeZiplist(data); err == nil {
+		t.Error("Expected an error for a truncated ziplist")
+	}
+}
+
+func TestDecodeListpack(t *testing.T) {
+	data := []byte{
+		0, 0, 0, 0, 0, 0, // total bytes, element count
+		0x81, 'a', 0x02, // 6 bit string
+		0x07, 0x01, // 7 bit uint 7
+		0xDF, 0xFF, 0x02, // 13 bit int -1
+		0xF1, 0xD4, 0xFE, 0x03, // int16 -300
+		0xF4, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0x7F, 0x09, // int64 max
+		0xE0, 0x80, // 12 bit string length
+	}
+	data = append(data, strings.Repeat("y", 128)...)
+	data = append(data, 0x82, 0x01) // backlen 130 in two bytes
+	data = append(data, 0xFF)
+
+	entries, err := decodeListpack(data)
+	if err != nil {
+		t.Fatalf("decodeListpack failed: %v", err)
+	}
+
+	expected := []string{"a", "7", "-1", "-300", "9223372036854775807", strings.Repeat("y", 128)}
+	if !reflect.DeepEqual(entries, expected) {
+		t.Errorf("Expected %v, got %v", expected, entries)
+	}
+}
+
+func TestDecodeListpackBacklenBounds(t *testing.T) {
+	// An entry of exactly 16383 bytes, a 32 bit string length header and
+	// 16378 bytes, takes three backlen bytes like in Redis
+	data := []byte{0, 0, 0, 0, 0, 0, 0xF0, 0xFA, 0x3F, 0x00, 0x00}
+	data = append(data, strings.Repeat("z", 16378)...)
+	data = append(data, 0x00, 0xFF, 0xFF) // backlen 16383 in three bytes
+	data = append(data, 0x81, 'a', 0x02)
+	data = append(data, 0xFF)
+
+	entries, err := decodeListpack(data)
+	if err != nil {
+		t.Fatalf("decodeListpack failed: %v", err)
+	}
+
+	expected := []string{strings.Repeat("z", 16378), "a"}
+	if !reflect.DeepEqual(entries, expected) {
+		t.Errorf("Expected 2 entries ending with \"a\", got %d entries", len(entries))
+	}
+}
+
+func TestDecodeIntset(t *testing.T) {
+	data := []byte{
+		0x04, 0, 0, 0, // int32 members
+		0x02, 0, 0, 0, // two members
+		0xFF, 0xFF, 0xFF, 0xFF,
+		0x40, 0x42, 0x0F, 0x00,
+	}
+
+	members, err := decodeIntset(data)
+	if err != nil {
+		t.Fatalf("decodeIntset failed: %v", err)
+	}
+
+	expected := []string{"-1", "1000000"}
+	if !reflect.DeepEqual(members, expected) {
+		t.Errorf("Expected %v, got %v", expected, members)
+	}
+}
+
+func TestDecodeIntsetInvalidCount(t *testing.T) {
+	tests := map[string][]byte{
+		"negative when signed": {0x02, 0, 0, 0, 0xFF, 0xFF, 0xFF, 0xFF},
+		"high bit set":         {0x02, 0, 0, 0, 0x00, 0x00, 0x00, 0x80, 0x01, 0x00},
+		"more than the data":   {0x02, 0, 0, 0, 0x02, 0, 0, 0, 0x01, 0x00},
+		"trailing bytes":       {0x02, 0, 0, 0, 0x01, 0, 0, 0, 0x01, 0x00, 0x02, 0x00},
+	}
+
+	for name, data := range tests {
+		if _, err := decodeIntset(data); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestDecodeZipmap(t *testing.T) {
+	data := []byte{
+		0x02,
+		0x03, 'f', 'o', 'o', 0x03, 0x00, 'b', 'a', 'r',
+		0x01, 'k', 0x01, 0x02, 'v', 0, 0, // two free bytes
+		0xFF,
+	}
+
+	hash, err := decodeZipmap(data)
+	if err != nil {
+		t.Fatalf("decodeZipmap failed: %v", err)
+	}
+
+	expected := Hash{"foo": "bar", "k": "v"}
+	if !reflect.DeepEqual(hash, expected) {
+		t.Errorf("Expected %v, got %v", expected, hash)
+	}
+}
@@ -0,0 +1,125 @@
+package rdb
+
+import "fmt"
+
+const (
+	lzfHashLog    = 14
+	lzfMaxLiteral = 1 << 5
+	lzfMaxOffset  = 1 << 13
+	lzfMaxMatch   = 7 + 255 + 2
+)
+
+// lzfDecompress expands LZF compressed data into exactly length bytes.
+//
+// The input is a sequence of chunks. A control byte below 32 starts a run of
+// control+1 literal bytes. Otherwise its top three bits hold the match length
+// minus two (7 meaning an extra length byte follows) and its low five bits,
+// together with the next byte, the distance back into the output minus one.
+func lzfDecompress(in []byte, length int) ([]byte, error) {
+	out := make([]byte, 0, length)
+
+	for ip := 0; ip < len(in); {
+		ctrl := int(in[ip])
+		ip++
+
+		if ctrl < lzfMaxLiteral {
+			run := ctrl + 1
+			if ip+run > len(in) {
+				return nil, fmt.Errorf("lzf literal run past end of input at byte %d", ip)
+			}
+			if len(out)+run > length {
+				return nil, fmt.Errorf("lzf output exceeds %d bytes at byte %d", length, ip)
+			}
+			out = append(out, in[ip:ip+run]...)
+			ip += run
+			continue
+		}
+
+		matchLength := ctrl >> 5
+		if matchLength == 7 {
+			if ip >= len(in) {
+				return nil, fmt.Errorf("lzf match length past end of input at byte %d", ip)
+			}
+			matchLength += int(in[ip])
+			ip++
+		}
+		if ip >= len(in) {
+			return nil, fmt.Errorf("lzf match offset past end of input at byte %d", ip)
+		}
+		ref := len(out) - (ctrl&0x1F)<<8 - int(in[ip]) - 1
+		ip++
+		if ref < 0 {
+			return nil, fmt.Errorf("lzf back reference before start of output at byte %d", ip)
+		}
+		if len(out)+matchLength+2 > length {
+			return nil, fmt.Errorf("lzf output exceeds %d bytes at byte %d", length, ip)
+		}
+
+		// The match may overlap the bytes it produces, so copy one at a time.
+		for i := range matchLength + 2 {
+			out = append(out, out[ref+i])
+		}
+	}
+
+	if len(out) != length {
+		return nil, fmt.Errorf("lzf decompressed %d bytes, expected %d", len(out), length)
+	}
+	return out, nil
+}
+
+// lzfCompress compresses in with LZF. Matches are found through a hash of
+// the next three bytes, trading some ratio for speed like the reference
+// implementation.
+func lzfCompress(in []byte) []byte {
+	var table [1 << lzfHashLog]int
+	out := make([]byte, 0, len(in))
+
+	literal := 0
+	ip := 0
+	for ip+2 < len(in) {
+		h := lzfHash(in[ip:])
+		ref := table[h] - 1
+		table[h] = ip + 1
+
+		offset := ip - ref - 1
+		if ref < 0 || offset >= lzfMaxOffset || in[ref] != in[ip] || in[ref+1] != in[ip+1] || in[ref+2] != in[ip+2] {
+			ip++
+			continue
+		}
+
+		matchLength := 3
+		for ip+matchLength < len(in) && matchLength < lzfMaxMatch && in[ref+matchLength] == in[ip+matchLength] {
+			matchLength++
+		}
+
+		out = appendLiterals(out, in[literal:ip])
+		encoded := matchLength - 2
+		if encoded < 7 {
+			out = append(out, byte(encoded<<5|offset>>8))
+		} else {
+			out = append(out, byte(7<<5|offset>>8), byte(encoded-7))
+		}
+		out = append(out, byte(offset))
+
+		ip += matchLength
+		literal = ip
+	}
+
+	return appendLiterals(out, in[literal:])
+}
+
+func lzfHash(p []byte) int {
+	v := uint32(p[0])<<16 | uint32(p[1])<<8 | uint32(p[2])
+	return int((v * 2654435761) >> (32 - lzfHashLog))
+}
+
+// appendLiterals appends literal runs of at most 32 bytes each.
+func appendLiterals(out, literals []byte) []byte {
+	for len(literals) > 0 {
+		run := min(len(literals), lzfMaxLiteral)
+		out = append(out, byte(run-1))
+		out = append(out, literals[:run]...)
+		literals = literals[run:]
+	}
+	return out
+}
@@ -0,0 +1,80 @@
+package rdb
+
+import (
+	"bytes"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestLZFDecompress(t *testing.T) {
+	// A one byte literal followed by an overlapping 29 byte back reference.
+	in := []byte{0x00, 'a', 0xE0, 20, 0x00}
+
+	out, err := lzfDecompress(in, 30)
+	if err != nil {
+		t.Fatalf("lzfDecompress failed: %v", err)
+	}
+	if string(out) != strings.Repeat("a", 30) {
+		t.Errorf("Expected 30 a's, got %q", out)
+	}
+}
+
+func TestLZFDecompressInvalid(t *testing.T) {
+	tests := map[string][]byte{
+		"literal past end":  {0x05, 'a'},
+		"reference too far": {0x00, 'a', 0x20, 0x05},
+		"missing offset":    {0x00, 'a', 0x20},
+		"wrong output size": {0x01, 'a', 'b'},
+		"output past size":  {0x00, 'a', 0xE0, 0xFF, 0x00},
+	}
+
+	for name, in := range tests {
+		if _, err := lzfDecompress(in, 3); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestLZFRoundTrip(t *testing.T) {
+	random := make([]byte, 5000)
+	rand.New(rand.NewSource(1)).Read(random)
+
+	inputs := [][]byte{
+		{},
+		[]byte("ab"),
+		[]byte(strings.Repeat("hello world ", 200)),
+		[]byte(strings.Repeat("x", 10000)),
+		random,
+	}
+
+	for _, in := range inputs {
+		out, err := lzfDecompress(lzfCompress(in), len(in))
+		if err != nil {
+			t.Fatalf("lzfDecompress failed for %d bytes: %v", len(in), err)
+		}
+		if !bytes.Equal(out, in) {
+			t.Errorf("Round trip of %d bytes did not match", len(in))
+		}
+	}
+}
+
+func TestWriterCompressesLongStrings(t *testing.T) {
+	value := strings.Repeat("compressible ", 50)
+
+	var buffer bytes.Buffer
+	writer := NewWriter(&buffer)
+	writer.WriteHeader()
+	writer.WriteSelectDB(0)
+	writer.WriteString("key", value, nil)
+	writer.WriteEOF()
+
+	if !bytes.Contains(buffer.Bytes(), []byte{StringEncLZF}) || buffer.Len() >= len(value) {
+		t.Errorf("Expected the value to be LZF compressed, file is %d bytes", buffer.Len())
+	}
+
+	keys := readKeys(t, buffer.Bytes())
+	if keys["key"].Value != value {
+		t.Errorf("Expected the compressed value to round trip")
+	}
+}
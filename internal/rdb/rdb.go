@@ -5,18 +5,48 @@ import (
 	"time"
 )
 
+// RDBValue is a decoded key. Value holds a string, List, Set, Hash or
+// SortedSet.
 type RDBValue struct {
-	Value     string
+	Value     any
 	ExpiresAt *time.Time
+
+	// Size is the number of bytes the key took in the file, including its
+	// expiry, type and name
+	Size int64
+}
+
+type List []string
+
+type Set []string
+
+type Hash map[string]string
+
+type SortedSet []SortedSetEntry
+
+type SortedSetEntry struct {
+	Member string
+	Score  float64
+}
+
+// AuxField is a metadata field from the start of the file, such as
+// redis-ver or ctime.
+type AuxField struct {
+	Key   string
+	Value string
 }
 
+// RDBData holds the keys of each database in the file, by database number,
+// and the aux fields in the order they were read.
 type RDBData struct {
-	Keys map[string]*RDBValue
+	Version   int
+	Aux       []AuxField
+	Databases map[int]map[string]*RDBValue
 }
 
 func NewRDBData() *RDBData {
 	return &RDBData{
-		Keys: make(map[string]*RDBValue),
+		Databases: make(map[int]map[string]*RDBValue),
 	}
 }
 
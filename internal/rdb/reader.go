@@ -1,16 +1,70 @@
 package rdb
 
 import (
+	"bufio"
 	"encoding/binary"
+	"errors"
 	"fmt"
 	"io"
+	"math"
 	"os"
 	"path/filepath"
+	"slices"
+	"strconv"
 	"time"
 )
 
+// Reader decodes an RDB file from any stream, such as a file on disk, the
+// snapshot a master sends during a full resync or a DUMP payload. The
+// stream is buffered, so it is read in large chunks rather than a byte at a
+// time.
 type Reader struct {
-	file *os.File
+	file    *checksumReader
+	closer  io.Closer
+	version int
+
+	// length is the size of the whole stream, or -1 when it is not known
+	length int64
+
+	// skipZeroChecksum accepts a zero footer, written by servers with
+	// checksums disabled, without verifying it
+	skipZeroChecksum bool
+
+	// progress is called with the bytes read so far as loading advances
+	progress         func(offset int64)
+	progressReported int64
+}
+
+// progressInterval is how many bytes are read between progress reports.
+const progressInterval = 1024 * 1024
+
+// checksumReader keeps a running CRC64 and offset of everything read
+// through it.
+type checksumReader struct {
+	reader *bufio.Reader
+	crc    uint64
+	offset int64
+	single [1]byte
+}
+
+func (c *checksumReader) Read(p []byte) (int, error) {
+	n, err := c.reader.Read(p)
+	if n > 0 {
+		c.crc = CRC64(c.crc, p[:n])
+		c.offset += int64(n)
+	}
+	return n, err
+}
+
+func (c *checksumReader) ReadByte() (byte, error) {
+	b, err := c.reader.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+	c.single[0] = b
+	c.crc = CRC64(c.crc, c.single[:])
+	c.offset++
+	return b, nil
 }
 
 func NewReader(dir, filename string) (*Reader, error) {
@@ -25,12 +79,53 @@ func NewReader(dir, filename string) (*Reader, error) {
 		return nil, fmt.Errorf("failed to open RDB file: %w", err)
 	}
 
-	return &Reader{file}, nil
+	reader := NewReaderFrom(file)
+	reader.closer = file
+	if info, err := file.Stat(); err == nil {
+		reader.length = info.Size()
+	}
+	return reader, nil
+}
+
+// NewReaderFrom returns a reader over an RDB stream. It reads ahead of the
+// data it decodes, so src should not be shared with other readers unless it
+// only holds the RDB file. When src reports its length, as a bytes.Reader
+// does, strings longer than what is left are rejected before being read.
+func NewReaderFrom(src io.Reader) *Reader {
+	length := int64(-1)
+	if sized, ok := src.(interface{ Len() int }); ok {
+		length = int64(sized.Len())
+	}
+
+	buffered, ok := src.(*bufio.Reader)
+	if !ok {
+		buffered = bufio.NewReaderSize(src, 64*1024)
+	}
+	return &Reader{file: &checksumReader{reader: buffered}, length: length, skipZeroChecksum: true}
+}
+
+// SkipZeroChecksum sets whether a zero checksum footer is accepted without
+// verification. It is accepted by default.
+func (r *Reader) SkipZeroChecksum(skip bool) {
+	r.skipZeroChecksum = skip
+}
+
+// OnProgress sets a function called with the number of bytes read so far,
+// after a key ends at least progressInterval bytes past the last call.
+func (r *Reader) OnProgress(progress func(offset int64)) {
+	r.progress = progress
+}
+
+func (r *Reader) reportProgress() {
+	if r.progress != nil && r.file.offset-r.progressReported >= progressInterval {
+		r.progressReported = r.file.offset
+		r.progress(r.file.offset)
+	}
 }
 
 func (r *Reader) Close() error {
-	if r.file != nil {
-		return r.file.Close()
+	if r.closer != nil {
+		return r.closer.Close()
 	}
 	return nil
 }
@@ -40,161 +135,253 @@ func (r *Reader) ReadRDB() (*RDBData, error) {
 		return NewRDBData(), nil
 	}
 
-	data := NewRDBData()
-
 	if err := r.readHeader(); err != nil {
 		return nil, fmt.Errorf("failed to read header: %w", err)
 	}
 
+	data, err := r.readBody()
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		err = errors.New("unexpected end of file")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("corrupt RDB file at byte %d: %w", r.file.offset, err)
+	}
+
+	return data, nil
+}
+
+// readBody reads the opcodes that follow the header until EOF. Keys belong
+// to the database selected last, 0 before any SELECTDB. Expiry and eviction
+// hints precede the key they apply to.
+func (r *Reader) readBody() (*RDBData, error) {
+	data := NewRDBData()
+	db := 0
+
+	var expiresAt *time.Time
+	keyStart := int64(-1)
+
 	for {
 		opCode, err := r.readByte()
-		if err == io.EOF {
-			break
-		}
 		if err != nil {
 			return nil, err
 		}
+		if keyStart < 0 {
+			keyStart = r.file.offset - 1
+		}
 
 		switch opCode {
-		case OpAux: // Start of a metadata subsection
-			if err := r.skipMetadata(); err != nil {
+		case OpEOF:
+			if expiresAt != nil {
+				return nil, errors.New("expiry without a key")
+			}
+			data.Version = r.version
+			return data, r.verifyChecksum()
+		case OpAux: // Metadata such as the version of the server
+			field, err := r.readAux()
+			if err != nil {
+				return nil, err
+			}
+			data.Aux = append(data.Aux, field)
+		case OpSelectDB:
+			index, err := r.readSize()
+			if err != nil {
 				return nil, err
 			}
-		case OpSelectDB: // Start of a database subsection
-			if err := r.readDatabase(data); err != nil {
+			db = int(index)
+		case OpResizeDB:
+			// Hash table sizes for the keys and the expires
+			if err := r.skipSizes(2); err != nil {
 				return nil, err
 			}
-		case OpEOF:
-			return data, nil
+		case OpSlotInfo:
+			// Cluster slot, slot size and expires slot size
+			if err := r.skipSizes(3); err != nil {
+				return nil, err
+			}
+		case OpFunction2:
+			// The source code of a function library
+			if _, err := r.readString(); err != nil {
+				return nil, err
+			}
+		case OpFunctionPreGA:
+			return nil, errors.New("functions from a pre-release of Redis 7.0 are not supported")
+		case OpModuleAux:
+			if err := r.skipModuleAux(); err != nil {
+				return nil, err
+			}
+		case OpExpireTime:
+			seconds, err := r.readUint32()
+			if err != nil {
+				return nil, err
+			}
+			t := time.Unix(int64(seconds), 0)
+			expiresAt = &t
+			continue
+		case OpExpireTimeMS:
+			// Written in the byte order of the host before version 9,
+			// which is little endian on every platform Redis ran on
+			milliseconds, err := r.readUint64()
+			if err != nil {
+				return nil, err
+			}
+			t := time.UnixMilli(int64(milliseconds))
+			expiresAt = &t
+			continue
+		case OpIdle:
+			// The LRU idle time of the next key
+			if err := r.skipSizes(1); err != nil {
+				return nil, err
+			}
+			continue
+		case OpFreq:
+			// The LFU counter of the next key
+			if _, err := r.readByte(); err != nil {
+				return nil, err
+			}
+			continue
 		default:
-			return nil, fmt.Errorf("unexpected byte: 0x%02X", opCode)
-		}
-	}
+			key, err := r.readString()
+			if err != nil {
+				return nil, err
+			}
+			value, err := r.readValue(opCode)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read key %q: %w", key, err)
+			}
 
-	return data, nil
-}
+			keys := data.Databases[db]
+			if keys == nil {
+				keys = make(map[string]*RDBValue)
+				data.Databases[db] = keys
+			}
+			keys[key] = &RDBValue{
+				Value:     value,
+				ExpiresAt: expiresAt,
+				Size:      r.file.offset - keyStart,
+			}
+			expiresAt = nil
+			r.reportProgress()
+		}
 
-func (r *Reader) readHeader() error {
-	header := make([]byte, len(RDBHeader))
-	_, err := io.ReadFull(r.file, header)
-	if err != nil {
-		return err
+		// Only expiry and eviction hints carry over to the next opcode
+		if expiresAt != nil {
+			return nil, fmt.Errorf("expiry followed by opcode 0x%02X instead of a key", opCode)
+		}
+		keyStart = -1
 	}
+}
 
-	if string(header) != RDBHeader {
-		return fmt.Errorf("invalid header: expected %s, got %s", RDBHeader, string(header))
+func (r *Reader) skipSizes(count int) error {
+	for range count {
+		if _, err := r.readSize(); err != nil {
+			return err
+		}
 	}
-
 	return nil
 }
 
-// skipMetadata skips the metdata key and the value
-func (r *Reader) skipMetadata() error {
-	if err := r.skipString(); err != nil {
+// skipModuleAux skips the data a module stores outside of keys: the module
+// id, when it is loaded, and the values the module wrote, each preceded by
+// its type, up to an EOF marker.
+func (r *Reader) skipModuleAux() error {
+	if err := r.skipSizes(1); err != nil { // module id
 		return err
 	}
-	return r.skipString()
-}
-
-func (r *Reader) skipString() error {
-	_, err := r.readString()
-	return err
-}
-
-func (r *Reader) readDatabase(data *RDBData) error {
-	// Read database index (size encoded)
-	_, err := r.readSize()
+	when, err := r.readSize()
 	if err != nil {
 		return err
 	}
-
-	// Check for hash table size information
-	nextByte, err := r.readByte()
-	if err != nil {
+	if when != ModuleOpcodeUint {
+		return fmt.Errorf("invalid module aux when opcode: %d", when)
+	}
+	if err := r.skipSizes(1); err != nil {
 		return err
 	}
 
-	if nextByte == OpResizeDB {
-		// Skip hash table sizes
-		if _, err := r.readSize(); err != nil { // key-value hash table size
-			return err
-		}
-		if _, err := r.readSize(); err != nil { // key-expires hash table size
+	for {
+		opCode, err := r.readSize()
+		if err != nil {
 			return err
 		}
-	} else {
-		// Put the byte back since it's not a resize op
-		r.file.Seek(-1, io.SeekCurrent)
-	}
 
-	// Read key-value pairs
-	for {
-		opCode, err := r.readByte()
-		if err == io.EOF {
-			break
+		switch opCode {
+		case ModuleOpcodeEOF:
+			return nil
+		case ModuleOpcodeSint, ModuleOpcodeUint:
+			err = r.skipSizes(1)
+		case ModuleOpcodeFloat:
+			_, err = r.readUint32()
+		case ModuleOpcodeDouble:
+			_, err = r.readUint64()
+		case ModuleOpcodeString:
+			_, err = r.readString()
+		default:
+			return fmt.Errorf("unknown module value opcode: %d", opCode)
 		}
 		if err != nil {
 			return err
 		}
+	}
+}
 
-		if opCode == OpSelectDB || opCode == OpEOF || opCode == OpAux {
-			r.file.Seek(-1, io.SeekCurrent)
-			break
-		}
-
-		var expiresAt *time.Time
-
-		switch opCode {
-		case OpExpireTime:
-			timestamp, err := r.readUint32()
-			if err != nil {
-				return err
-			}
-			t := time.Unix(int64(timestamp), 0)
-			expiresAt = &t
-
-			opCode, err = r.readByte()
-			if err != nil {
-				return err
-			}
-		case OpExpireTimeMS:
-			timestamp, err := r.readUint64()
-			if err != nil {
-				return err
-			}
-			t := time.Unix(int64(timestamp/1000), int64((timestamp%1000)*1000000))
-			expiresAt = &t
+// verifyChecksum compares the CRC64 of everything read so far, up to and
+// including the EOF opcode, with the footer that follows it.
+func (r *Reader) verifyChecksum() error {
+	if r.version < ChecksumRDBVersion {
+		return nil
+	}
+	expected := r.file.crc
 
-			opCode, err = r.readByte()
-			if err != nil {
-				return err
-			}
-		}
+	footer, err := r.readUint64()
+	if err != nil {
+		return err
+	}
+	if footer == 0 && r.skipZeroChecksum {
+		return nil
+	}
+	if footer != expected {
+		r.file.offset -= 8
+		return fmt.Errorf("checksum mismatch: computed 0x%016x, file has 0x%016x", expected, footer)
+	}
 
-		// Validate value type
-		if opCode != ValueTypeString {
-			return fmt.Errorf("unsupported value type: 0x%02x", opCode)
-		}
+	return nil
+}
 
-		key, err := r.readString()
-		if err != nil {
-			return err
-		}
+// readHeader reads the magic string and the version, which must be between
+// MinRDBVersion and MaxRDBVersion.
+func (r *Reader) readHeader() error {
+	header := make([]byte, len(RDBHeader))
+	_, err := io.ReadFull(r.file, header)
+	if err != nil {
+		return err
+	}
 
-		value, err := r.readString()
-		if err != nil {
-			return err
-		}
+	magic, version := string(header[:len(RDBMagicString)]), string(header[len(RDBMagicString):])
+	if magic != RDBMagicString {
+		return fmt.Errorf("invalid header: expected %s, got %q", RDBMagicString, magic)
+	}
 
-		data.Keys[key] = &RDBValue{
-			Value:     value,
-			ExpiresAt: expiresAt,
-		}
+	r.version, err = strconv.Atoi(version)
+	if err != nil {
+		return fmt.Errorf("invalid version: %q", version)
+	}
+	if r.version < MinRDBVersion || r.version > MaxRDBVersion {
+		return fmt.Errorf("unsupported version %d, expected %d to %d", r.version, MinRDBVersion, MaxRDBVersion)
 	}
 
 	return nil
 }
 
+// readAux reads the key and value of a metadata field.
+func (r *Reader) readAux() (AuxField, error) {
+	key, err := r.readString()
+	if err != nil {
+		return AuxField{}, err
+	}
+	value, err := r.readString()
+	return AuxField{Key: key, Value: value}, err
+}
+
 func (r *Reader) readSize() (uint64, error) {
 	b, err := r.readByte()
 	if err != nil {
@@ -212,11 +399,7 @@ func (r *Reader) readSize() (uint64, error) {
 		}
 		return (uint64(b&SizeValueMask) << 8) | uint64(next), nil
 	case 2:
-		val, err := r.readUint32BigEndian()
-		if err != nil {
-			return 0, err
-		}
-		return uint64(val), nil
+		return r.readLongSize(b)
 	case 3:
 		return 0, fmt.Errorf("special string encoding not supported in size context")
 	}
@@ -224,32 +407,56 @@ func (r *Reader) readSize() (uint64, error) {
 	return 0, fmt.Errorf("invalid size encoding")
 }
 
+// readLongSize reads a size that does not fit in 14 bits: a 32-bit big
+// endian size after 0x80, or a 64-bit one after 0x81.
+func (r *Reader) readLongSize(b byte) (uint64, error) {
+	switch b {
+	case Size32Bit:
+		val, err := r.readUint32BigEndian()
+		return uint64(val), err
+	case Size64Bit:
+		var bytes [8]byte
+		if _, err := io.ReadFull(r.file, bytes[:]); err != nil {
+			return 0, err
+		}
+		size := binary.BigEndian.Uint64(bytes[:])
+		if size > math.MaxInt {
+			return 0, fmt.Errorf("size %d out of range", size)
+		}
+		return size, nil
+	default:
+		return 0, fmt.Errorf("invalid size encoding: 0x%02x", b)
+	}
+}
+
 func (r *Reader) readString() (string, error) {
-	size, err := r.readStringSize()
+	size, encoded, err := r.readStringSize()
 	if err != nil {
 		return "", err
 	}
 
-	if size&SizeEncodingMask == SizeEncodingMask {
+	if encoded {
 		switch size {
 		case StringEnc8BitInt:
 			b, err := r.readByte()
 			if err != nil {
 				return "", err
 			}
-			return fmt.Sprintf("%d", b), nil
+			return fmt.Sprintf("%d", int8(b)), nil
 		case StringEnc16BitInt:
 			val, err := r.readUint16()
 			if err != nil {
 				return "", err
 			}
-			return fmt.Sprintf("%d", val), nil
+			return fmt.Sprintf("%d", int16(val)), nil
 		case StringEnc32BitInt:
 			val, err := r.readUint32()
 			if err != nil {
 				return "", err
 			}
-			return fmt.Sprintf("%d", val), nil
+			return fmt.Sprintf("%d", int32(val)), nil
+		case StringEncLZF:
+			return r.readCompressedString()
 		default:
 			return "", fmt.Errorf("unsupported string encoding: 0x%02x", size)
 		}
@@ -259,8 +466,7 @@ func (r *Reader) readString() (string, error) {
 		return "", nil
 	}
 
-	bytes := make([]byte, size)
-	_, err = io.ReadFull(r.file, bytes)
+	bytes, err := r.readBytes(size)
 	if err != nil {
 		return "", err
 	}
@@ -268,39 +474,92 @@ func (r *Reader) readString() (string, error) {
 	return string(bytes), nil
 }
 
-func (r *Reader) readStringSize() (uint64, error) {
+// readChunkSize is the most memory reserved ahead of the data when the
+// length of the stream is not known.
+const readChunkSize = 1024 * 1024
+
+// readBytes reads size bytes. A size past the end of a stream of known
+// length is rejected up front, and on other streams memory is reserved in
+// chunks as data arrives, so a corrupt size fails on the truncated input
+// instead of allocating it all.
+func (r *Reader) readBytes(size uint64) ([]byte, error) {
+	if size > math.MaxInt {
+		return nil, fmt.Errorf("size %d out of range", size)
+	}
+	if r.length >= 0 && size > uint64(r.length-r.file.offset) {
+		return nil, fmt.Errorf("size %d with %d bytes left: %w", size, r.length-r.file.offset, io.ErrUnexpectedEOF)
+	}
+
+	buffer := make([]byte, 0, min(size, readChunkSize))
+	for uint64(len(buffer)) < size {
+		start := len(buffer)
+		chunk := int(min(size-uint64(start), readChunkSize))
+		buffer = slices.Grow(buffer, chunk)[:start+chunk]
+		if _, err := io.ReadFull(r.file, buffer[start:]); err != nil {
+			return nil, err
+		}
+	}
+	return buffer, nil
+}
+
+// readCompressedString reads an LZF compressed string: the compressed and
+// uncompressed lengths followed by the compressed bytes.
+func (r *Reader) readCompressedString() (string, error) {
+	compressedLength, err := r.readSize()
+	if err != nil {
+		return "", err
+	}
+	length, err := r.readSize()
+	if err != nil {
+		return "", err
+	}
+
+	compressed, err := r.readBytes(compressedLength)
+	if err != nil {
+		return "", err
+	}
+	// Each chunk of at most three bytes expands to no more than a full
+	// match, which bounds the output before it is allocated
+	if length > uint64(len(compressed))*lzfMaxMatch {
+		return "", fmt.Errorf("lzf length %d too large for %d compressed bytes", length, len(compressed))
+	}
+
+	decompressed, err := lzfDecompress(compressed, int(length))
+	if err != nil {
+		return "", err
+	}
+	return string(decompressed), nil
+}
+
+// readStringSize reads the length of a string. When the top two bits of
+// the first byte are set, the string has a special encoding instead, which
+// is returned as that byte with encoded set.
+func (r *Reader) readStringSize() (size uint64, encoded bool, err error) {
 	b, err := r.readByte()
 	if err != nil {
-		return 0, err
+		return 0, false, err
 	}
 
 	encodingType := (b & SizeEncodingMask) >> 6
 	switch encodingType {
 	case 0:
-		return uint64(b & SizeValueMask), nil
+		return uint64(b & SizeValueMask), false, nil
 	case 1:
 		next, err := r.readByte()
 		if err != nil {
-			return 0, err
+			return 0, false, err
 		}
-		return (uint64(b&SizeValueMask) << 8) | uint64(next), nil
+		return (uint64(b&SizeValueMask) << 8) | uint64(next), false, nil
 	case 2:
-		val, err := r.readUint32BigEndian()
-		if err != nil {
-			return 0, err
-		}
-		return uint64(val), nil
-	case 3:
-		return uint64(b), nil
+		size, err := r.readLongSize(b)
+		return size, false, err
+	default:
+		return uint64(b), true, nil
 	}
-
-	return 0, fmt.Errorf("invalid string size encoding")
 }
 
 func (r *Reader) readByte() (byte, error) {
-	var b [1]byte
-	_, err := io.ReadFull(r.file, b[:])
-	return b[0], err
+	return r.file.ReadByte()
 }
 
 func (r *Reader) readUint16() (uint16, error) {
@@ -0,0 +1,367 @@
+package rdb
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"math/rand/v2"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+// rdbFile wraps a database section in a header and EOF marker.
+func rdbFile(body ...byte) []byte {
+	data := append([]byte(RDBHeader), OpSelectDB, 0x00)
+	data = append(data, body...)
+	return append(data, OpEOF, 0, 0, 0, 0, 0, 0, 0, 0)
+}
+
+func readKeys(t *testing.T, data []byte) map[string]*RDBValue {
+	t.Helper()
+
+	result, err := NewReaderFrom(bytes.NewReader(data)).ReadRDB()
+	if err != nil {
+		t.Fatalf("ReadRDB failed: %v", err)
+	}
+	return result.Databases[0]
+}
+
+func TestReadList(t *testing.T) {
+	keys := readKeys(t, rdbFile(ValueTypeList, 1, 'l', 2, 1, 'a', 1, 'b'))
+
+	expected := List{"a", "b"}
+	if !reflect.DeepEqual(keys["l"].Value, expected) {
+		t.Errorf("Expected %v, got %v", expected, keys["l"].Value)
+	}
+}
+
+func TestReadSet(t *testing.T) {
+	keys := readKeys(t, rdbFile(ValueTypeSet, 1, 's', 2, 1, 'x', StringEnc8BitInt, 7))
+
+	expected := Set{"x", "7"}
+	if !reflect.DeepEqual(keys["s"].Value, expected) {
+		t.Errorf("Expected %v, got %v", expected, keys["s"].Value)
+	}
+}
+
+func TestReadHash(t *testing.T) {
+	keys := readKeys(t, rdbFile(ValueTypeHash, 1, 'h', 1, 1, 'f', 1, 'v'))
+
+	expected := Hash{"f": "v"}
+	if !reflect.DeepEqual(keys["h"].Value, expected) {
+		t.Errorf("Expected %v, got %v", expected, keys["h"].Value)
+	}
+}
+
+func TestReadSortedSetStringScores(t *testing.T) {
+	keys := readKeys(t, rdbFile(ValueTypeZSet, 1, 'z', 2, 1, 'a', 3, '1', '.', '5', 1, 'b', 254))
+
+	entries := keys["z"].Value.(SortedSet)
+	if len(entries) != 2 || entries[0] != (SortedSetEntry{"a", 1.5}) {
+		t.Errorf("Unexpected entries %v", entries)
+	}
+	if entries[1].Member != "b" || !math.IsInf(entries[1].Score, 1) {
+		t.Errorf("Expected b with score +inf, got %v", entries[1])
+	}
+}
+
+func TestReadSortedSetBinaryScores(t *testing.T) {
+	var buffer bytes.Buffer
+	writer := NewWriter(&buffer)
+	writer.WriteHeader()
+	writer.WriteSelectDB(0)
+	writer.WriteSortedSet("z", []SortedSetEntry{{"a", -2.25}, {"b", 10}}, nil)
+	writer.WriteEOF()
+
+	keys := readKeys(t, buffer.Bytes())
+
+	expected := SortedSet{{"a", -2.25}, {"b", 10}}
+	if !reflect.DeepEqual(keys["z"].Value, expected) {
+		t.Errorf("Expected %v, got %v", expected, keys["z"].Value)
+	}
+}
+
+func TestReadOversizedCount(t *testing.T) {
+	// A count of 2^32-1 elements in a file that ends after one of them
+	// fails as truncated rather than reserving memory for the count
+	for _, valueType := range []byte{ValueTypeList, ValueTypeSet, ValueTypeHash, ValueTypeZSet2} {
+		data := rdbFile(valueType, 1, 'k', Size32Bit, 0xFF, 0xFF, 0xFF, 0xFF, 1, 'a')
+
+		if _, err := NewReaderFrom(bytes.NewReader(data)).ReadRDB(); err == nil {
+			t.Errorf("Expected an error for value type %d with an oversized count", valueType)
+		}
+	}
+}
+
+func TestReadOversizedString(t *testing.T) {
+	// A string size past the end of the input, or beyond what fits in an
+	// int, is an error rather than an allocation of that size
+	sizes := [][]byte{
+		{0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF},
+		{0x00, 0x00, 0x00, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF},
+	}
+	for _, size := range sizes {
+		data := rdbFile(append([]byte{ValueTypeString, 1, 'k', Size64Bit}, append(size, 'a')...)...)
+
+		if _, err := NewReaderFrom(bytes.NewReader(data)).ReadRDB(); err == nil {
+			t.Errorf("Expected an error for string size %x", size)
+		}
+		// Without a known length the string is read until the input runs out
+		if _, err := NewReaderFrom(io.MultiReader(bytes.NewReader(data))).ReadRDB(); err == nil {
+			t.Errorf("Expected an error for string size %x from a stream", size)
+		}
+	}
+}
+
+func TestReadUnsupportedType(t *testing.T) {
+	data := rdbFile(0x42, 1, 'k')
+
+	if _, err := NewReaderFrom(bytes.NewReader(data)).ReadRDB(); err == nil {
+		t.Error("Expected an error for an unknown value type")
+	}
+}
+
+func TestReadQuicklist2(t *testing.T) {
+	listpack := []byte{0, 0, 0, 0, 0, 0, 0x81, 'a', 0x02, 0x05, 0x01, 0xFF}
+
+	body := []byte{ValueTypeListQuicklist2, 1, 'l', 2}
+	body = append(body, QuicklistNodePacked, byte(len(listpack)))
+	body = append(body, listpack...)
+	body = append(body, QuicklistNodePlain, 3, 'b', 'i', 'g')
+
+	keys := readKeys(t, rdbFile(body...))
+
+	expected := List{"a", "5", "big"}
+	if !reflect.DeepEqual(keys["l"].Value, expected) {
+		t.Errorf("Expected %v, got %v", expected, keys["l"].Value)
+	}
+}
+
+func TestReadHashListpack(t *testing.T) {
+	listpack := []byte{0, 0, 0, 0, 0, 0, 0x81, 'f', 0x02, 0x81, 'v', 0x02, 0xFF}
+
+	body := []byte{ValueTypeHashListpack, 1, 'h', byte(len(listpack))}
+	keys := readKeys(t, rdbFile(append(body, listpack...)...))
+
+	expected := Hash{"f": "v"}
+	if !reflect.DeepEqual(keys["h"].Value, expected) {
+		t.Errorf("Expected %v, got %v", expected, keys["h"].Value)
+	}
+}
+
+func TestReadSortedSetZiplist(t *testing.T) {
+	ziplist := []byte{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0x00, 0x01, 'm', 0x03, 0x03, '2', '.', '5', 0xFF}
+
+	body := []byte{ValueTypeZSetZiplist, 1, 'z', byte(len(ziplist))}
+	keys := readKeys(t, rdbFile(append(body, ziplist...)...))
+
+	expected := SortedSet{{"m", 2.5}}
+	if !reflect.DeepEqual(keys["z"].Value, expected) {
+		t.Errorf("Expected %v, got %v", expected, keys["z"].Value)
+	}
+}
+
+func writtenFile(t *testing.T) []byte {
+	t.Helper()
+
+	var buffer bytes.Buffer
+	writer := NewWriter(&buffer)
+	writer.WriteHeader()
+	writer.WriteSelectDB(0)
+	writer.WriteString("foo", "bar", nil)
+	writer.WriteEOF()
+	return buffer.Bytes()
+}
+
+func TestReadVerifiesChecksum(t *testing.T) {
+	data := writtenFile(t)
+
+	keys := readKeys(t, data)
+	if keys["foo"].Value != "bar" {
+		t.Errorf("Expected foo to be loaded, got %v", keys["foo"])
+	}
+
+	data[len(data)-12] ^= 0x01 // flip a bit in the value "bar"
+	_, err := NewReaderFrom(bytes.NewReader(data)).ReadRDB()
+	if err == nil {
+		t.Fatal("Expected a checksum error")
+	}
+
+	expected := fmt.Sprintf("at byte %d: checksum mismatch", len(data)-8)
+	if !strings.Contains(err.Error(), expected) {
+		t.Errorf("Expected error containing %q, got %q", expected, err)
+	}
+}
+
+func TestReadTruncatedFile(t *testing.T) {
+	data := writtenFile(t)
+	truncated := data[:len(data)-11]
+
+	_, err := NewReaderFrom(bytes.NewReader(truncated)).ReadRDB()
+	if err == nil || !strings.Contains(err.Error(), "unexpected end of file") {
+		t.Errorf("Expected an unexpected end of file error, got %v", err)
+	}
+}
+
+func TestReadZeroChecksum(t *testing.T) {
+	data := writtenFile(t)
+	copy(data[len(data)-8:], make([]byte, 8))
+
+	readKeys(t, data)
+
+	reader := NewReaderFrom(bytes.NewReader(data))
+	reader.SkipZeroChecksum(false)
+	if _, err := reader.ReadRDB(); err == nil {
+		t.Error("Expected a zero checksum to be verified when skipping is disabled")
+	}
+}
+
+func TestReadAuxFieldsAndKeySizes(t *testing.T) {
+	var buffer bytes.Buffer
+	writer := NewWriter(&buffer)
+	writer.WriteHeader()
+	writer.WriteAux("redis-ver", "7.2.0")
+	writer.WriteAux("redis-bits", "64")
+	writer.WriteSelectDB(0)
+	writer.WriteString("foo", "bar", nil)
+	writer.WriteEOF()
+
+	data, err := NewReaderFrom(bytes.NewReader(buffer.Bytes())).ReadRDB()
+	if err != nil {
+		t.Fatalf("ReadRDB failed: %v", err)
+	}
+
+	expected := []AuxField{{"redis-ver", "7.2.0"}, {"redis-bits", "64"}}
+	if !reflect.DeepEqual(data.Aux, expected) {
+		t.Errorf("Expected aux fields %v, got %v", expected, data.Aux)
+	}
+
+	// Type byte, then the key and value with a length byte each
+	if size := data.Databases[0]["foo"].Size; size != 1+4+4 {
+		t.Errorf("Expected foo to take 9 bytes, got %d", size)
+	}
+}
+
+func TestReadFromStream(t *testing.T) {
+	data := writtenFile(t)
+
+	// A stream that cannot seek and returns a byte per read, like a slow
+	// socket, decodes the same as the file
+	result, err := NewReaderFrom(iotest.OneByteReader(bytes.NewReader(data))).ReadRDB()
+	if err != nil {
+		t.Fatalf("ReadRDB failed: %v", err)
+	}
+	if result.Databases[0]["foo"].Value != "bar" {
+		t.Errorf("Expected foo to be loaded, got %v", result.Databases[0]["foo"])
+	}
+}
+
+func TestReadVersions(t *testing.T) {
+	body := []byte{OpSelectDB, 0, ValueTypeString, 1, 'k', 1, 'v', OpEOF}
+	footer := make([]byte, 8)
+
+	for _, version := range []string{"0009", "0010", "0011", "0012"} {
+		data := append(append([]byte("REDIS"+version), body...), footer...)
+		result, err := NewReaderFrom(bytes.NewReader(data)).ReadRDB()
+		if err != nil {
+			t.Errorf("Version %s: ReadRDB failed: %v", version, err)
+			continue
+		}
+		if expected, _ := strconv.Atoi(version); result.Version != expected {
+			t.Errorf("Version %s: got %d", version, result.Version)
+		}
+	}
+
+	// Files before version 5 end at the EOF opcode, without a checksum
+	data := append([]byte("REDIS0004"), body...)
+	if _, err := NewReaderFrom(bytes.NewReader(data)).ReadRDB(); err != nil {
+		t.Errorf("Version 0004: ReadRDB failed: %v", err)
+	}
+
+	for _, header := range []string{"REDIS0013", "REDIS0000", "REDISabcd", "RODIS0011"} {
+		data := append(append([]byte(header), body...), footer...)
+		if _, err := NewReaderFrom(bytes.NewReader(data)).ReadRDB(); err == nil {
+			t.Errorf("Header %s: expected an error", header)
+		}
+	}
+}
+
+func TestReadSkipsOptionalOpcodes(t *testing.T) {
+	var body []byte
+	body = append(body, OpFunction2, 4, 'c', 'o', 'd', 'e')
+	// Module aux: 64-bit module id, when opcode and value, a string and
+	// an unsigned integer, then EOF
+	body = append(body, OpModuleAux, Size64Bit, 1, 2, 3, 4, 5, 6, 7, 8, ModuleOpcodeUint, 2)
+	body = append(body, ModuleOpcodeString, 1, 'x', ModuleOpcodeUint, 7, ModuleOpcodeDouble, 0, 0, 0, 0, 0, 0, 0, 0, ModuleOpcodeEOF)
+	body = append(body, OpSelectDB, 1, OpResizeDB, 2, 1, OpSlotInfo, 5, 2, 1)
+	// Expiry in seconds followed by LRU and LFU hints
+	body = append(body, OpExpireTime, 0x00, 0xF1, 0x53, 0x65, OpIdle, 10, OpFreq, 5, ValueTypeString, 1, 'a', 1, '1')
+	body = append(body, OpFreq, 3, ValueTypeString, 1, 'b', 1, '2')
+
+	data := append(append([]byte("REDIS0012"), body...), OpEOF, 0, 0, 0, 0, 0, 0, 0, 0)
+	result, err := NewReaderFrom(bytes.NewReader(data)).ReadRDB()
+	if err != nil {
+		t.Fatalf("ReadRDB failed: %v", err)
+	}
+
+	keys := result.Databases[1]
+	if len(keys) != 2 || keys["a"].Value != "1" || keys["b"].Value != "2" {
+		t.Fatalf("Expected keys a and b in database 1, got %v", keys)
+	}
+	if keys["a"].ExpiresAt == nil || keys["a"].ExpiresAt.Unix() != 0x6553F100 {
+		t.Errorf("Expected a to expire at 0x6553F100, got %v", keys["a"].ExpiresAt)
+	}
+	if keys["b"].ExpiresAt != nil {
+		t.Errorf("Expected the expiry of a not to carry over to b")
+	}
+	// The expiry and hints are part of the key: 5 + 2 + 2 bytes before the
+	// type, key and value
+	if keys["a"].Size != 9+5 {
+		t.Errorf("Expected a to take 14 bytes, got %d", keys["a"].Size)
+	}
+}
+
+func TestReadRejectsPreGAFunctions(t *testing.T) {
+	data := append([]byte(RDBHeader), OpFunctionPreGA, OpEOF, 0, 0, 0, 0, 0, 0, 0, 0)
+	if _, err := NewReaderFrom(bytes.NewReader(data)).ReadRDB(); err == nil {
+		t.Error("Expected an error for pre-release functions")
+	}
+}
+
+func TestReadReportsProgress(t *testing.T) {
+	var buffer bytes.Buffer
+	writer := NewWriter(&buffer)
+	writer.WriteHeader()
+	writer.WriteSelectDB(0)
+	// Random values are not shrunk by LZF compression
+	random := rand.New(rand.NewPCG(1, 2))
+	value := make([]byte, progressInterval/2+1)
+	for i := range value {
+		value[i] = byte(random.Uint32())
+	}
+	for i := range 5 {
+		writer.WriteString(fmt.Sprintf("key:%d", i), string(value), nil)
+	}
+	writer.WriteEOF()
+
+	var offsets []int64
+	reader := NewReaderFrom(bytes.NewReader(buffer.Bytes()))
+	reader.OnProgress(func(offset int64) { offsets = append(offsets, offset) })
+	if _, err := reader.ReadRDB(); err != nil {
+		t.Fatalf("ReadRDB failed: %v", err)
+	}
+
+	// Every second key crosses another interval
+	if len(offsets) != 2 {
+		t.Fatalf("Expected 2 progress reports, got %v", offsets)
+	}
+	for i, offset := range offsets {
+		if offset < int64(i+1)*progressInterval || offset > int64(buffer.Len()) {
+			t.Errorf("Progress report %d at offset %d is out of range", i, offset)
+		}
+	}
+}
@@ -0,0 +1,234 @@
+package rdb
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+)
+
+// maxPreallocatedItems bounds the memory reserved for a collection before
+// its elements have been read, as its size comes from untrusted input.
+const maxPreallocatedItems = 1024
+
+// readValue reads a value of the given type, returning a string, List, Set,
+// Hash or SortedSet. Compact encodings are decoded into the same types.
+func (r *Reader) readValue(valueType byte) (any, error) {
+	switch valueType {
+	case ValueTypeString:
+		return r.readString()
+	case ValueTypeList:
+		items, err := r.readStrings()
+		return List(items), err
+	case ValueTypeSet:
+		members, err := r.readStrings()
+		return Set(members), err
+	case ValueTypeHash:
+		return r.readHash()
+	case ValueTypeZSet:
+		return r.readSortedSet(r.readStringScore)
+	case ValueTypeZSet2:
+		return r.readSortedSet(r.readBinaryScore)
+	case ValueTypeHashZipmap:
+		return readEncoded(r, decodeZipmap)
+	case ValueTypeListZiplist:
+		items, err := readEncoded(r, decodeZiplist)
+		return List(items), err
+	case ValueTypeSetIntset:
+		members, err := readEncoded(r, decodeIntset)
+		return Set(members), err
+	case ValueTypeSetListpack:
+		members, err := readEncoded(r, decodeListpack)
+		return Set(members), err
+	case ValueTypeHashZiplist:
+		return readPairs(r, decodeZiplist, pairs)
+	case ValueTypeHashListpack:
+		return readPairs(r, decodeListpack, pairs)
+	case ValueTypeZSetZiplist:
+		return readPairs(r, decodeZiplist, scoredPairs)
+	case ValueTypeZSetListpack:
+		return readPairs(r, decodeListpack, scoredPairs)
+	case ValueTypeListQuicklist:
+		return r.readQuicklist()
+	case ValueTypeListQuicklist2:
+		return r.readQuicklist2()
+	default:
+		return nil, fmt.Errorf("unsupported value type: 0x%02x", valueType)
+	}
+}
+
+// readEncoded reads a string holding a compact encoding and decodes it.
+func readEncoded[T any](r *Reader, decode func([]byte) (T, error)) (T, error) {
+	data, err := r.readString()
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return decode([]byte(data))
+}
+
+// readPairs reads a ziplist or listpack of alternating entries and groups
+// them into a Hash or SortedSet.
+func readPairs[T any](r *Reader, decode func([]byte) ([]string, error), group func([]string) (T, error)) (T, error) {
+	entries, err := readEncoded(r, decode)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return group(entries)
+}
+
+// readQuicklist reads a list stored as a sequence of ziplists.
+func (r *Reader) readQuicklist() (List, error) {
+	size, err := r.readSize()
+	if err != nil {
+		return nil, err
+	}
+
+	var list List
+	for range size {
+		items, err := readEncoded(r, decodeZiplist)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, items...)
+	}
+
+	return list, nil
+}
+
+// readQuicklist2 reads a list stored as a sequence of nodes, each either a
+// listpack or a single plain element.
+func (r *Reader) readQuicklist2() (List, error) {
+	size, err := r.readSize()
+	if err != nil {
+		return nil, err
+	}
+
+	var list List
+	for range size {
+		container, err := r.readSize()
+		if err != nil {
+			return nil, err
+		}
+
+		switch container {
+		case QuicklistNodePlain:
+			item, err := r.readString()
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, item)
+		case QuicklistNodePacked:
+			items, err := readEncoded(r, decodeListpack)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, items...)
+		default:
+			return nil, fmt.Errorf("unknown quicklist container: %d", container)
+		}
+	}
+
+	return list, nil
+}
+
+// readStrings reads a size followed by that many strings.
+func (r *Reader) readStrings() ([]string, error) {
+	size, err := r.readSize()
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]string, 0, min(size, maxPreallocatedItems))
+	for range size {
+		item, err := r.readString()
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
+func (r *Reader) readHash() (Hash, error) {
+	size, err := r.readSize()
+	if err != nil {
+		return nil, err
+	}
+
+	hash := make(Hash, min(size, maxPreallocatedItems))
+	for range size {
+		field, err := r.readString()
+		if err != nil {
+			return nil, err
+		}
+		value, err := r.readString()
+		if err != nil {
+			return nil, err
+		}
+		hash[field] = value
+	}
+
+	return hash, nil
+}
+
+func (r *Reader) readSortedSet(readScore func() (float64, error)) (SortedSet, error) {
+	size, err := r.readSize()
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make(SortedSet, 0, min(size, maxPreallocatedItems))
+	for range size {
+		member, err := r.readString()
+		if err != nil {
+			return nil, err
+		}
+		score, err := readScore()
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, SortedSetEntry{member, score})
+	}
+
+	return entries, nil
+}
+
+// readStringScore reads a score of the original zset encoding: a length
+// byte followed by its ASCII form, with 253-255 standing for nan, inf and
+// -inf.
+func (r *Reader) readStringScore() (float64, error) {
+	length, err := r.readByte()
+	if err != nil {
+		return 0, err
+	}
+
+	switch length {
+	case 253:
+		return math.NaN(), nil
+	case 254:
+		return math.Inf(1), nil
+	case 255:
+		return math.Inf(-1), nil
+	}
+
+	buffer := make([]byte, length)
+	if _, err := io.ReadFull(r.file, buffer); err != nil {
+		return 0, err
+	}
+
+	return strconv.ParseFloat(string(buffer), 64)
+}
+
+// readBinaryScore reads a score of the zset2 encoding, a little endian
+// IEEE 754 double.
+func (r *Reader) readBinaryScore() (float64, error) {
+	var buffer [8]byte
+	if _, err := io.ReadFull(r.file, buffer[:]); err != nil {
+		return 0, err
+	}
+	return math.Float64frombits(binary.LittleEndian.Uint64(buffer[:])), nil
+}
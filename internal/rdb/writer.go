@@ -0,0 +1,262 @@
+package rdb
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"math"
+	"strconv"
+	"time"
+)
+
+type Writer struct {
+	writer   *bufio.Writer
+	checksum *checksumWriter
+}
+
+// checksumWriter keeps a running CRC64 of everything written through it.
+type checksumWriter struct {
+	writer io.Writer
+	crc    uint64
+}
+
+func (c *checksumWriter) Write(p []byte) (int, error) {
+	n, err := c.writer.Write(p)
+	c.crc = CRC64(c.crc, p[:n])
+	return n, err
+}
+
+func NewWriter(w io.Writer) *Writer {
+	checksum := &checksumWriter{writer: w}
+	return &Writer{
+		writer:   bufio.NewWriter(checksum),
+		checksum: checksum,
+	}
+}
+
+func (w *Writer) WriteHeader() error {
+	_, err := w.writer.WriteString(RDBHeader)
+	return err
+}
+
+func (w *Writer) WriteAux(key, value string) error {
+	if err := w.writer.WriteByte(OpAux); err != nil {
+		return err
+	}
+	if err := w.writeString(key); err != nil {
+		return err
+	}
+	return w.writeString(value)
+}
+
+func (w *Writer) WriteSelectDB(index int) error {
+	if err := w.writer.WriteByte(OpSelectDB); err != nil {
+		return err
+	}
+	return w.writeSize(uint64(index))
+}
+
+func (w *Writer) WriteResizeDB(keys, expires int) error {
+	if err := w.writer.WriteByte(OpResizeDB); err != nil {
+		return err
+	}
+	if err := w.writeSize(uint64(keys)); err != nil {
+		return err
+	}
+	return w.writeSize(uint64(expires))
+}
+
+// WriteString writes a string key-value pair, preceded by its expiry if set.
+func (w *Writer) WriteString(key, value string, expiresAt *time.Time) error {
+	if err := w.writeKey(ValueTypeString, key, expiresAt); err != nil {
+		return err
+	}
+	return w.writeString(value)
+}
+
+// WriteList writes a list key using the plain list encoding.
+func (w *Writer) WriteList(key string, items []string, expiresAt *time.Time) error {
+	if err := w.writeKey(ValueTypeList, key, expiresAt); err != nil {
+		return err
+	}
+	return w.writeStrings(items)
+}
+
+// WriteSet writes a set key using the plain set encoding.
+func (w *Writer) WriteSet(key string, members []string, expiresAt *time.Time) error {
+	if err := w.writeKey(ValueTypeSet, key, expiresAt); err != nil {
+		return err
+	}
+	return w.writeStrings(members)
+}
+
+// WriteHash writes a hash key using the plain hash encoding.
+func (w *Writer) WriteHash(key string, fields map[string]string, expiresAt *time.Time) error {
+	if err := w.writeKey(ValueTypeHash, key, expiresAt); err != nil {
+		return err
+	}
+	return w.writeHash(fields)
+}
+
+func (w *Writer) writeHash(fields map[string]string) error {
+	if err := w.writeSize(uint64(len(fields))); err != nil {
+		return err
+	}
+	for field, value := range fields {
+		if err := w.writeString(field); err != nil {
+			return err
+		}
+		if err := w.writeString(value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteSortedSet writes a sorted set key using the zset2 encoding, which
+// stores scores as binary doubles.
+func (w *Writer) WriteSortedSet(key string, entries []SortedSetEntry, expiresAt *time.Time) error {
+	if err := w.writeKey(ValueTypeZSet2, key, expiresAt); err != nil {
+		return err
+	}
+	return w.writeSortedSet(entries)
+}
+
+func (w *Writer) writeSortedSet(entries []SortedSetEntry) error {
+	if err := w.writeSize(uint64(len(entries))); err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if err := w.writeString(entry.Member); err != nil {
+			return err
+		}
+		var score [8]byte
+		binary.LittleEndian.PutUint64(score[:], math.Float64bits(entry.Score))
+		if _, err := w.writer.Write(score[:]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeStrings writes a count followed by each string, as used by the plain
+// list and set encodings.
+func (w *Writer) writeStrings(items []string) error {
+	if err := w.writeSize(uint64(len(items))); err != nil {
+		return err
+	}
+	for _, item := range items {
+		if err := w.writeString(item); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteEOF terminates the file with the CRC64 of everything written and
+// flushes it.
+func (w *Writer) WriteEOF() error {
+	if err := w.writer.WriteByte(OpEOF); err != nil {
+		return err
+	}
+	if err := w.writer.Flush(); err != nil {
+		return err
+	}
+
+	var checksum [8]byte
+	binary.LittleEndian.PutUint64(checksum[:], w.checksum.crc)
+	_, err := w.checksum.writer.Write(checksum[:])
+	return err
+}
+
+// writeKey writes the optional expiry, the value type and the key.
+func (w *Writer) writeKey(valueType byte, key string, expiresAt *time.Time) error {
+	if err := w.writeExpiry(expiresAt); err != nil {
+		return err
+	}
+	if err := w.writer.WriteByte(valueType); err != nil {
+		return err
+	}
+	return w.writeString(key)
+}
+
+func (w *Writer) writeExpiry(expiresAt *time.Time) error {
+	if expiresAt == nil {
+		return nil
+	}
+	if err := w.writer.WriteByte(OpExpireTimeMS); err != nil {
+		return err
+	}
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], uint64(expiresAt.UnixMilli()))
+	_, err := w.writer.Write(b[:])
+	return err
+}
+
+// writeString writes s, using the compact integer encoding when s is the
+// canonical form of a 32-bit integer and LZF compression when s is longer
+// than 20 bytes and compresses by at least 4.
+func (w *Writer) writeString(s string) error {
+	if encoded, ok := encodeInteger(s); ok {
+		_, err := w.writer.Write(encoded)
+		return err
+	}
+
+	if len(s) > 20 {
+		if compressed := lzfCompress([]byte(s)); len(compressed) <= len(s)-4 {
+			return w.writeCompressed(compressed, len(s))
+		}
+	}
+
+	if err := w.writeSize(uint64(len(s))); err != nil {
+		return err
+	}
+	_, err := w.writer.WriteString(s)
+	return err
+}
+
+func (w *Writer) writeCompressed(compressed []byte, length int) error {
+	if err := w.writer.WriteByte(StringEncLZF); err != nil {
+		return err
+	}
+	if err := w.writeSize(uint64(len(compressed))); err != nil {
+		return err
+	}
+	if err := w.writeSize(uint64(length)); err != nil {
+		return err
+	}
+	_, err := w.writer.Write(compressed)
+	return err
+}
+
+func (w *Writer) writeSize(size uint64) error {
+	switch {
+	case size < 1<<6:
+		return w.writer.WriteByte(byte(size))
+	case size < 1<<14:
+		_, err := w.writer.Write([]byte{byte(size>>8) | 0x40, byte(size)})
+		return err
+	default:
+		var b [5]byte
+		b[0] = 0x80
+		binary.BigEndian.PutUint32(b[1:], uint32(size))
+		_, err := w.writer.Write(b[:])
+		return err
+	}
+}
+
+func encodeInteger(s string) ([]byte, bool) {
+	value, err := strconv.ParseInt(s, 10, 32)
+	if err != nil || strconv.FormatInt(value, 10) != s {
+		return nil, false
+	}
+
+	switch {
+	case value >= math.MinInt8 && value <= math.MaxInt8:
+		return []byte{StringEnc8BitInt, byte(value)}, true
+	case value >= math.MinInt16 && value <= math.MaxInt16:
+		return binary.LittleEndian.AppendUint16([]byte{StringEnc16BitInt}, uint16(value)), true
+	default:
+		return binary.LittleEndian.AppendUint32([]byte{StringEnc32BitInt}, uint32(value)), true
+	}
+}
@@ -0,0 +1,94 @@
+package rdb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math/rand/v2"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriterChecksumFooter(t *testing.T) {
+	var buffer bytes.Buffer
+	writer := NewWriter(&buffer)
+
+	expiresAt := time.UnixMilli(1700000000000)
+	steps := []func() error{
+		writer.WriteHeader,
+		func() error { return writer.WriteAux("redis-ver", "7.2.0") },
+		func() error { return writer.WriteSelectDB(0) },
+		func() error { return writer.WriteResizeDB(2, 1) },
+		func() error { return writer.WriteString("foo", "bar", &expiresAt) },
+		func() error { return writer.WriteList("list", []string{"a", "-5", "300"}, nil) },
+		writer.WriteEOF,
+	}
+	for _, step := range steps {
+		if err := step(); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+	}
+
+	data := buffer.Bytes()
+	if !bytes.HasPrefix(data, []byte(RDBHeader)) {
+		t.Errorf("Expected header %q", RDBHeader)
+	}
+
+	body, footer := data[:len(data)-8], data[len(data)-8:]
+	if body[len(body)-1] != OpEOF {
+		t.Errorf("Expected EOF opcode before the checksum")
+	}
+
+	expected := CRC64(0, body)
+	if binary.LittleEndian.Uint64(footer) != expected {
+		t.Errorf("Expected checksum 0x%x, got 0x%x", expected, binary.LittleEndian.Uint64(footer))
+	}
+}
+
+func TestEmptyRDBFileChecksum(t *testing.T) {
+	data := EmptyRDBFile()
+	body, footer := data[:len(data)-8], data[len(data)-8:]
+
+	if binary.LittleEndian.Uint64(footer) != CRC64(0, body) {
+		t.Errorf("Expected the canned RDB file to carry a valid checksum")
+	}
+}
+
+func TestWriterStringRoundTrip(t *testing.T) {
+	values := []string{"", "bar", "0", "-1", "127", "-128", "300", "-40000", "2147483647", "007", "99999999999"}
+
+	// Lengths at the boundaries of the size encodings, including those whose
+	// low byte looks like a special encoding, in a form LZF cannot shrink
+	random := rand.New(rand.NewPCG(1, 2))
+	for _, length := range []int{63, 64, 191, 192, 200, 255, 448, 1000, 16383, 16384} {
+		value := make([]byte, length)
+		for i := range value {
+			value[i] = byte('a' + random.IntN(26))
+		}
+		values = append(values, string(value))
+	}
+
+	// Around the threshold above which compressible strings are compressed
+	values = append(values, strings.Repeat("x", 20), strings.Repeat("x", 21), strings.Repeat("x", 200))
+
+	var buffer bytes.Buffer
+	writer := NewWriter(&buffer)
+	writer.WriteHeader()
+	writer.WriteSelectDB(0)
+	for i, value := range values {
+		writer.WriteString(string(rune('a'+i)), value, nil)
+	}
+	writer.WriteEOF()
+
+	data, err := NewReaderFrom(bytes.NewReader(buffer.Bytes())).ReadRDB()
+	if err != nil {
+		t.Fatalf("ReadRDB failed: %v", err)
+	}
+
+	for i, value := range values {
+		key := string(rune('a' + i))
+		if data.Databases[0][key] == nil || data.Databases[0][key].Value != value {
+			t.Errorf("Expected %s=%q, got %+v", key, value, data.Databases[0][key])
+		}
+	}
+}
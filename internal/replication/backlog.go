@@ -0,0 +1,69 @@
+package replication
+
+// Backlog is a fixed size circular buffer holding the most recent bytes of
+// the replication stream, so a replica that briefly lost its connection can
+// resume without a full resync.
+type Backlog struct {
+	buffer []byte
+	// end is the replication offset just past the last byte written
+	end int64
+	// length is the number of valid bytes in the buffer
+	length int64
+}
+
+func NewBacklog(size int64) *Backlog {
+	return &Backlog{buffer: make([]byte, size)}
+}
+
+// Write appends data, overwriting the oldest bytes when the buffer is full.
+func (b *Backlog) Write(data []byte) {
+	size := int64(len(b.buffer))
+	b.end += int64(len(data))
+	if size == 0 {
+		return
+	}
+
+	if int64(len(data)) > size {
+		data = data[int64(len(data))-size:]
+	}
+
+	position := (b.end - int64(len(data))) % size
+	copied := copy(b.buffer[position:], data)
+	copy(b.buffer, data[copied:])
+
+	b.length = min(b.length+int64(len(data)), size)
+}
+
+// ReadFrom returns the bytes from offset to the end of the stream. It reports
+// false when part of that range has already been overwritten.
+func (b *Backlog) ReadFrom(offset int64) ([]byte, bool) {
+	if offset < b.end-b.length || offset > b.end {
+		return nil, false
+	}
+
+	size := int64(len(b.buffer))
+	result := make([]byte, b.end-offset)
+	if len(result) == 0 {
+		return result, true
+	}
+
+	position := offset % size
+	copied := copy(result, b.buffer[position:])
+	copy(result[copied:], b.buffer)
+
+	return result, true
+}
+
+// End returns the replication offset just past the last byte written.
+func (b *Backlog) End() int64 {
+	return b.end
+}
+
+func (b *Backlog) Size() int64 {
+	return int64(len(b.buffer))
+}
+
+// Length returns the number of stream bytes currently held.
+func (b *Backlog) Length() int64 {
+	return b.length
+}
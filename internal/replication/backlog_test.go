@@ -0,0 +1,48 @@
+package replication
+
+import "testing"
+
+func TestBacklogReadFrom(t *testing.T) {
+	backlog := NewBacklog(8)
+	backlog.Write([]byte("abcdef"))
+
+	data, ok := backlog.ReadFrom(2)
+	if !ok || string(data) != "cdef" {
+		t.Errorf("Expected 'cdef', got %q (ok=%v)", data, ok)
+	}
+
+	data, ok = backlog.ReadFrom(6)
+	if !ok || len(data) != 0 {
+		t.Errorf("Expected empty range, got %q (ok=%v)", data, ok)
+	}
+}
+
+func TestBacklogWrapsAround(t *testing.T) {
+	backlog := NewBacklog(8)
+	backlog.Write([]byte("abcdef"))
+	backlog.Write([]byte("ghij"))
+
+	if _, ok := backlog.ReadFrom(1); ok {
+		t.Error("Expected overwritten offset to be unavailable")
+	}
+
+	data, ok := backlog.ReadFrom(2)
+	if !ok || string(data) != "cdefghij" {
+		t.Errorf("Expected 'cdefghij', got %q (ok=%v)", data, ok)
+	}
+
+	backlog.Write([]byte("0123456789"))
+	data, ok = backlog.ReadFrom(backlog.End() - 8)
+	if !ok || string(data) != "23456789" {
+		t.Errorf("Expected '23456789', got %q (ok=%v)", data, ok)
+	}
+}
+
+func TestBacklogRejectsFutureOffset(t *testing.T) {
+	backlog := NewBacklog(8)
+	backlog.Write([]byte("abc"))
+
+	if _, ok := backlog.ReadFrom(4); ok {
+		t.Error("Expected offset past the end to be unavailable")
+	}
+}
@@ -0,0 +1,80 @@
+package replication
+
+import (
+	"sync"
+
+	"github.com/md-talim/codecrafters-redis-go/internal/config"
+)
+
+// Manager tracks the replication role of the server. It always holds the
+// master state, and the link to the master while acting as a replica.
+type Manager struct {
+	config  *config.Config
+	master  *Master
+	replica *Replica
+	handler Handler
+	mu      sync.RWMutex
+}
+
+func NewManager(cfg *config.Config) *Manager {
+	return &Manager{
+		config: cfg,
+		master: NewMaster(cfg.ReplBacklogSize),
+	}
+}
+
+func (m *Manager) Master() *Master {
+	return m.master
+}
+
+// Replica returns the link to the master, or nil when acting as a master.
+func (m *Manager) Replica() *Replica {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.replica
+}
+
+// Start connects to the master configured with --replicaof, if any. The
+// handler applies what is received from any master from now on.
+func (m *Manager) Start(handler Handler) {
+	m.mu.Lock()
+	m.handler = handler
+	m.mu.Unlock()
+
+	if m.config.IsReplica() {
+		host, port := m.config.GetMasterHostPort()
+		m.ReplicaOf(host, port)
+	}
+}
+
+// ReplicaOf turns the server into a replica of the given master, replacing
+// the link to any previous master. It reports false if the server already
+// replicates from that master.
+func (m *Manager) ReplicaOf(host, port string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.replica != nil {
+		if m.replica.MasterHost() == host && m.replica.MasterPort() == port {
+			return false
+		}
+		m.replica.Stop()
+	}
+
+	m.config.SetReplicaOf(host + " " + port)
+	m.replica = NewReplica(host, port, m.config.Port, m.handler)
+	m.replica.Start()
+	return true
+}
+
+// PromoteToMaster closes the link to the master, if any, keeping the dataset.
+func (m *Manager) PromoteToMaster() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.replica != nil {
+		m.replica.Stop()
+		m.replica = nil
+	}
+	m.config.SetReplicaOf("")
+}
@@ -0,0 +1,378 @@
+package replication
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/md-talim/codecrafters-redis-go/internal/resp"
+	"github.com/md-talim/codecrafters-redis-go/internal/session"
+)
+
+// replicaQueueSize bounds how many propagated commands may wait for a slow
+// replica before it is disconnected.
+const replicaQueueSize = 16384
+
+// Master holds the replication state of a server acting as a master.
+type Master struct {
+	replID   string
+	backlog  *Backlog
+	replicas map[*session.Session]*replicaConn
+	mu       sync.RWMutex
+
+	// acked is closed and replaced whenever a replica acknowledges an offset
+	acked chan struct{}
+
+	// writeMu serializes write commands with their propagation
+	writeMu sync.Mutex
+
+	// streamDB is the database selected in the replication stream, or -1
+	// when the next write must select one. Guarded by writeMu.
+	streamDB int
+}
+
+// replicaConn is a connected replica with its own outbound queue, so a slow
+// replica cannot stall the clients executing writes.
+type replicaConn struct {
+	session *session.Session
+	queue   chan []byte
+	closed  chan struct{}
+	once    sync.Once
+
+	// ackOffset is the last offset the replica reported through REPLCONF ACK
+	// and lastAck when it did, or when it connected before its first ACK
+	ackOffset int64
+	lastAck   time.Time
+}
+
+func NewMaster(backlogSize int64) *Master {
+	return &Master{
+		replID:   generateReplID(),
+		backlog:  NewBacklog(backlogSize),
+		replicas: make(map[*session.Session]*replicaConn),
+		acked:    make(chan struct{}),
+	}
+}
+
+func (m *Master) ReplID() string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.replID
+}
+
+func (m *Master) Offset() int64 {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.backlog.End()
+}
+
+// ReplicaInfo describes a connected replica.
+type ReplicaInfo struct {
+	IP        string
+	Port      string
+	AckOffset int64
+	LastAck   time.Time
+}
+
+// BacklogInfo describes the contents of the replication backlog.
+type BacklogInfo struct {
+	Size            int64
+	FirstByteOffset int64
+	Length          int64
+}
+
+func (m *Master) Replicas() []ReplicaInfo {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	replicas := make([]ReplicaInfo, 0, len(m.replicas))
+	for s, replica := range m.replicas {
+		ip := ""
+		if s.Conn != nil {
+			ip, _, _ = net.SplitHostPort(s.Conn.RemoteAddr().String())
+		}
+		replicas = append(replicas, ReplicaInfo{
+			IP:        ip,
+			Port:      s.ListeningPort,
+			AckOffset: replica.ackOffset,
+			LastAck:   replica.lastAck,
+		})
+	}
+
+	return replicas
+}
+
+func (m *Master) Backlog() BacklogInfo {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return BacklogInfo{
+		Size:            m.backlog.Size(),
+		FirstByteOffset: m.backlog.End() - m.backlog.Length() + 1,
+		Length:          m.backlog.Length(),
+	}
+}
+
+// Write runs execute, which applies a write command against database db, and
+// propagates the command to every replica if it succeeded, preceded by a
+// SELECT when the stream was on another database. Writes are serialized so
+// replicas receive them in the order they were applied.
+func (m *Master) Write(db int, command []resp.Value, execute func() resp.Value) resp.Value {
+	m.writeMu.Lock()
+	defer m.writeMu.Unlock()
+
+	result := execute()
+	if _, isError := result.(*resp.SimpleError); !isError {
+		if db != m.streamDB {
+			m.propagate(encodeCommand("SELECT", strconv.Itoa(db)))
+			m.streamDB = db
+		}
+		m.propagate(resp.NewArray(command).Serialize())
+	}
+
+	return result
+}
+
+// Exclusive runs fn while no write command executes, so fn sees the dataset
+// and everything logged or propagated about it at the same point.
+func (m *Master) Exclusive(fn func()) {
+	m.writeMu.Lock()
+	defer m.writeMu.Unlock()
+	fn()
+}
+
+// FullResync answers a PSYNC request with +FULLRESYNC followed by an RDB
+// snapshot, then attaches the session as a replica. The snapshot is sent as a
+// bulk string without trailing CRLF.
+func (m *Master) FullResync(s *session.Session, snapshot func() ([]byte, error)) error {
+	// Take the snapshot and register the replica without any write in between
+	m.writeMu.Lock()
+	data, err := snapshot()
+	if err != nil {
+		m.writeMu.Unlock()
+		return err
+	}
+	replica := m.addReplica(s)
+	if m.streamDB != 0 {
+		// The replica starts on database 0 after loading the snapshot
+		m.streamDB = -1
+	}
+	m.mu.RLock()
+	header := resp.NewSimpleString(fmt.Sprintf("FULLRESYNC %s %d", m.replID, m.backlog.End()))
+	m.mu.RUnlock()
+	m.writeMu.Unlock()
+
+	if _, err := s.Conn.Write(header.Serialize()); err != nil {
+		m.RemoveReplica(s)
+		return err
+	}
+
+	payload := fmt.Appendf(nil, "$%d%s", len(data), resp.CRLF)
+	payload = append(payload, data...)
+	if _, err := s.Conn.Write(payload); err != nil {
+		m.RemoveReplica(s)
+		return err
+	}
+
+	go m.stream(replica)
+	return nil
+}
+
+// PartialResync attaches the session as a replica resuming at offset, the
+// next byte it expects as sent in PSYNC. It reports false without writing
+// anything when the replid differs or the range is no longer in the backlog,
+// in which case a full resync is needed.
+func (m *Master) PartialResync(s *session.Session, replID string, offset int64) (bool, error) {
+	m.writeMu.Lock()
+	m.mu.RLock()
+	missing, ok := m.backlog.ReadFrom(offset - 1)
+	ok = ok && replID == m.replID
+	m.mu.RUnlock()
+	if !ok {
+		m.writeMu.Unlock()
+		return false, nil
+	}
+	replica := m.addReplica(s)
+	m.writeMu.Unlock()
+
+	header := resp.NewSimpleString("CONTINUE " + replID)
+	if _, err := s.Conn.Write(header.Serialize()); err != nil {
+		m.RemoveReplica(s)
+		return true, err
+	}
+
+	if _, err := s.Conn.Write(missing); err != nil {
+		m.RemoveReplica(s)
+		return true, err
+	}
+
+	go m.stream(replica)
+	return true, nil
+}
+
+// RemoveReplica detaches the session if it is a replica.
+func (m *Master) RemoveReplica(s *session.Session) {
+	m.mu.Lock()
+	replica, exists := m.replicas[s]
+	delete(m.replicas, s)
+	m.mu.Unlock()
+
+	if exists {
+		replica.close()
+	}
+}
+
+// Ack records the offset a replica has processed and the time it reported
+// it, and wakes up WAIT callers.
+func (m *Master) Ack(s *session.Session, offset int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	replica, exists := m.replicas[s]
+	if !exists {
+		return
+	}
+
+	replica.ackOffset = max(replica.ackOffset, offset)
+	replica.lastAck = time.Now()
+	close(m.acked)
+	m.acked = make(chan struct{})
+}
+
+// Wait blocks until at least numReplicas replicas have acknowledged every
+// write made before the call, or until the timeout expires. A zero timeout
+// waits forever. It returns the number of replicas that acknowledged.
+func (m *Master) Wait(numReplicas int, timeout time.Duration) int {
+	target := m.Offset()
+
+	acknowledged, acked := m.countAcked(target)
+	if acknowledged >= numReplicas {
+		return acknowledged
+	}
+
+	m.requestAcks()
+
+	var expired <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		expired = timer.C
+	}
+
+	for {
+		select {
+		case <-acked:
+			acknowledged, acked = m.countAcked(target)
+			if acknowledged >= numReplicas {
+				return acknowledged
+			}
+		case <-expired:
+			acknowledged, _ = m.countAcked(target)
+			return acknowledged
+		}
+	}
+}
+
+func (m *Master) ReplicaCount() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return len(m.replicas)
+}
+
+// countAcked returns how many replicas acknowledged target, along with the
+// channel signalling the next acknowledgement.
+func (m *Master) countAcked(target int64) (int, <-chan struct{}) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	count := 0
+	for _, replica := range m.replicas {
+		if replica.ackOffset >= target {
+			count++
+		}
+	}
+
+	return count, m.acked
+}
+
+// requestAcks asks every replica to report its offset with REPLCONF GETACK.
+func (m *Master) requestAcks() {
+	m.writeMu.Lock()
+	defer m.writeMu.Unlock()
+
+	getAck := []resp.Value{
+		resp.NewBulkString("REPLCONF"),
+		resp.NewBulkString("GETACK"),
+		resp.NewBulkString("*"),
+	}
+	m.propagate(resp.NewArray(getAck).Serialize())
+}
+
+func (m *Master) addReplica(s *session.Session) *replicaConn {
+	replica := &replicaConn{
+		session: s,
+		queue:   make(chan []byte, replicaQueueSize),
+		closed:  make(chan struct{}),
+		lastAck: time.Now(),
+	}
+
+	m.mu.Lock()
+	m.replicas[s] = replica
+	m.mu.Unlock()
+
+	return replica
+}
+
+func (m *Master) propagate(data []byte) {
+	m.mu.Lock()
+	m.backlog.Write(data)
+
+	var overflowed []*session.Session
+	for s, replica := range m.replicas {
+		select {
+		case replica.queue <- data:
+		default:
+			overflowed = append(overflowed, s)
+		}
+	}
+	m.mu.Unlock()
+
+	for _, s := range overflowed {
+		fmt.Printf("%d: replica output queue full, disconnecting\n", s.ID)
+		m.RemoveReplica(s)
+		s.Conn.Close()
+	}
+}
+
+// stream writes queued commands to the replica until it is removed.
+func (m *Master) stream(replica *replicaConn) {
+	for {
+		select {
+		case data := <-replica.queue:
+			if _, err := replica.session.Conn.Write(data); err != nil {
+				fmt.Printf("%d: replica write error: %v\n", replica.session.ID, err)
+				m.RemoveReplica(replica.session)
+				return
+			}
+		case <-replica.closed:
+			return
+		}
+	}
+}
+
+func (r *replicaConn) close() {
+	r.once.Do(func() { close(r.closed) })
+}
+
+// generateReplID returns a random 40 character replication ID.
+func generateReplID() string {
+	var b [20]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic(fmt.Sprintf("failed to generate replication id: %v", err))
+	}
+	return hex.EncodeToString(b[:])
+}
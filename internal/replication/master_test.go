@@ -0,0 +1,236 @@
+package replication
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/md-talim/codecrafters-redis-go/internal/resp"
+	"github.com/md-talim/codecrafters-redis-go/internal/session"
+)
+
+func attachReplica(t *testing.T, master *Master) *resp.Parser {
+	t.Helper()
+
+	serverConn, clientConn := net.Pipe()
+	t.Cleanup(func() {
+		serverConn.Close()
+		clientConn.Close()
+	})
+
+	go master.FullResync(session.New(1, serverConn), func() ([]byte, error) {
+		return []byte("REDIS0011\xff"), nil
+	})
+
+	reader := bufio.NewReader(clientConn)
+	parser := resp.NewParser(reader)
+	if _, err := parser.Parse(); err != nil {
+		t.Fatalf("Failed to read FULLRESYNC: %v", err)
+	}
+	if _, err := parser.ReadRDB(); err != nil {
+		t.Fatalf("Failed to read snapshot: %v", err)
+	}
+
+	return parser
+}
+
+func TestMasterPropagatesWrites(t *testing.T) {
+	master := NewMaster(1024)
+	parser := attachReplica(t, master)
+
+	set := []resp.Value{resp.NewBulkString("SET"), resp.NewBulkString("foo"), resp.NewBulkString("bar")}
+	master.Write(0, set, func() resp.Value { return resp.NewSimpleString("OK") })
+
+	failed := []resp.Value{resp.NewBulkString("RPUSH"), resp.NewBulkString("foo")}
+	master.Write(0, failed, func() resp.Value { return resp.NewSimpleError("ERR failed") })
+
+	push := []resp.Value{resp.NewBulkString("RPUSH"), resp.NewBulkString("list"), resp.NewBulkString("a")}
+	master.Write(0, push, func() resp.Value { return resp.NewInteger("1") })
+
+	for _, expected := range []string{"SET foo bar", "RPUSH list a"} {
+		command, err := parser.Parse()
+		if err != nil && err != io.EOF {
+			t.Fatalf("Failed to read propagated command: %v", err)
+		}
+		if command.String() != expected {
+			t.Errorf("Expected %q, got %q", expected, command.String())
+		}
+	}
+
+	expectedOffset := int64(len(resp.NewArray(set).Serialize()) + len(resp.NewArray(push).Serialize()))
+	if master.Offset() != expectedOffset {
+		t.Errorf("Expected offset %d, got %d", expectedOffset, master.Offset())
+	}
+}
+
+func TestMasterRemoveReplica(t *testing.T) {
+	master := NewMaster(1024)
+	attachReplica(t, master)
+
+	if master.ReplicaCount() != 1 {
+		t.Fatalf("Expected 1 replica, got %d", master.ReplicaCount())
+	}
+
+	for s := range master.replicas {
+		master.RemoveReplica(s)
+	}
+
+	if master.ReplicaCount() != 0 {
+		t.Errorf("Expected 0 replicas, got %d", master.ReplicaCount())
+	}
+}
+
+func TestMasterPartialResync(t *testing.T) {
+	master := NewMaster(1024)
+
+	set := []resp.Value{resp.NewBulkString("SET"), resp.NewBulkString("foo"), resp.NewBulkString("bar")}
+	master.Write(0, set, func() resp.Value { return resp.NewSimpleString("OK") })
+	push := []resp.Value{resp.NewBulkString("RPUSH"), resp.NewBulkString("list"), resp.NewBulkString("a")}
+	master.Write(0, push, func() resp.Value { return resp.NewInteger("1") })
+
+	serverConn, clientConn := net.Pipe()
+	defer serverConn.Close()
+	defer clientConn.Close()
+
+	// The replica has processed the SET and asks for the next byte
+	offset := int64(len(resp.NewArray(set).Serialize())) + 1
+	go func() {
+		resumed, err := master.PartialResync(session.New(1, serverConn), master.ReplID(), offset)
+		if !resumed || err != nil {
+			t.Errorf("Expected partial resync, got resumed=%v err=%v", resumed, err)
+		}
+	}()
+
+	parser := resp.NewParser(clientConn)
+	reply, err := parser.Parse()
+	if err != nil {
+		t.Fatalf("Failed to read reply: %v", err)
+	}
+	if reply.String() != "CONTINUE "+master.ReplID() {
+		t.Errorf("Expected CONTINUE, got %q", reply.String())
+	}
+
+	command, err := parser.Parse()
+	if err != nil {
+		t.Fatalf("Failed to read backlog: %v", err)
+	}
+	if command.String() != "RPUSH list a" {
+		t.Errorf("Expected 'RPUSH list a', got %q", command.String())
+	}
+}
+
+func TestMasterPartialResyncFallsBack(t *testing.T) {
+	master := NewMaster(8)
+
+	set := []resp.Value{resp.NewBulkString("SET"), resp.NewBulkString("foo"), resp.NewBulkString("bar")}
+	master.Write(0, set, func() resp.Value { return resp.NewSimpleString("OK") })
+
+	tests := []struct {
+		name   string
+		replID string
+		offset int64
+	}{
+		{"unknown replid", "0000000000000000000000000000000000000000", master.Offset() + 1},
+		{"offset outside backlog", master.ReplID(), 1},
+	}
+
+	for _, test := range tests {
+		resumed, err := master.PartialResync(session.Detached(), test.replID, test.offset)
+		if resumed || err != nil {
+			t.Errorf("%s: expected fallback, got resumed=%v err=%v", test.name, resumed, err)
+		}
+	}
+}
+
+func TestMasterWaitWithoutWrites(t *testing.T) {
+	master := NewMaster(1024)
+	attachReplica(t, master)
+
+	if acknowledged := master.Wait(0, time.Second); acknowledged != 1 {
+		t.Errorf("Expected 1 replica, got %d", acknowledged)
+	}
+}
+
+func TestMasterWaitForAck(t *testing.T) {
+	master := NewMaster(1024)
+	parser := attachReplica(t, master)
+
+	set := []resp.Value{resp.NewBulkString("SET"), resp.NewBulkString("foo"), resp.NewBulkString("bar")}
+	master.Write(0, set, func() resp.Value { return resp.NewSimpleString("OK") })
+	target := master.Offset()
+
+	result := make(chan int, 1)
+	go func() { result <- master.Wait(1, 5*time.Second) }()
+
+	for _, expected := range []string{"SET foo bar", "REPLCONF GETACK *"} {
+		command, err := parser.Parse()
+		if err != nil {
+			t.Fatalf("Failed to read propagated command: %v", err)
+		}
+		if command.String() != expected {
+			t.Errorf("Expected %q, got %q", expected, command.String())
+		}
+	}
+
+	before := time.Now()
+	for s := range master.replicas {
+		master.Ack(s, target)
+	}
+
+	if acknowledged := <-result; acknowledged != 1 {
+		t.Errorf("Expected 1 replica, got %d", acknowledged)
+	}
+	if replicas := master.Replicas(); len(replicas) != 1 || replicas[0].AckOffset != target || replicas[0].LastAck.Before(before) {
+		t.Errorf("Expected the ACK offset and time to be recorded, got %+v", replicas)
+	}
+}
+
+func TestMasterWaitTimeout(t *testing.T) {
+	master := NewMaster(1024)
+	parser := attachReplica(t, master)
+	go func() {
+		for {
+			if _, err := parser.Parse(); err != nil {
+				return
+			}
+		}
+	}()
+
+	set := []resp.Value{resp.NewBulkString("SET"), resp.NewBulkString("foo"), resp.NewBulkString("bar")}
+	master.Write(0, set, func() resp.Value { return resp.NewSimpleString("OK") })
+
+	start := time.Now()
+	if acknowledged := master.Wait(1, 50*time.Millisecond); acknowledged != 0 {
+		t.Errorf("Expected 0 replicas, got %d", acknowledged)
+	}
+	if time.Since(start) < 50*time.Millisecond {
+		t.Error("Expected WAIT to block until the timeout")
+	}
+}
+
+func TestMasterSelectsDatabaseInStream(t *testing.T) {
+	master := NewMaster(1024)
+
+	set := []resp.Value{resp.NewBulkString("SET"), resp.NewBulkString("foo"), resp.NewBulkString("bar")}
+	master.Write(2, set, func() resp.Value { return resp.NewSimpleString("OK") })
+
+	parser := attachReplica(t, master)
+
+	master.Write(2, set, func() resp.Value { return resp.NewSimpleString("OK") })
+	master.Write(2, set, func() resp.Value { return resp.NewSimpleString("OK") })
+	master.Write(0, set, func() resp.Value { return resp.NewSimpleString("OK") })
+
+	// The replica starts on database 0 after the snapshot, so the stream
+	// selects database 2 again before the first write
+	for _, expected := range []string{"SELECT 2", "SET foo bar", "SET foo bar", "SELECT 0", "SET foo bar"} {
+		command, err := parser.Parse()
+		if err != nil {
+			t.Fatalf("Failed to read propagated command: %v", err)
+		}
+		if command.String() != expected {
+			t.Errorf("Expected %q, got %q", expected, command.String())
+		}
+	}
+}
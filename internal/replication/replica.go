@@ -0,0 +1,340 @@
+package replication
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/md-talim/codecrafters-redis-go/internal/resp"
+	"github.com/md-talim/codecrafters-redis-go/internal/session"
+)
+
+const reconnectDelay = time.Second
+
+// ackInterval is how often a replica reports its offset unprompted.
+const ackInterval = time.Second
+
+// Handler applies what a replica receives from its master.
+type Handler interface {
+	LoadRDB(snapshot io.Reader) error
+	Evaluate(*session.Session, resp.Value) resp.Value
+}
+
+// Replica is the link from a replica to its master. It remembers the
+// master's replid and the offset processed so far, so that Run can resume
+// with a partial resync after the connection drops.
+type Replica struct {
+	masterHost    string
+	masterPort    string
+	listeningPort string
+	handler       Handler
+	session       *session.Session
+
+	replID string
+	offset int64
+	linkUp bool
+	conn   net.Conn
+	mu     sync.RWMutex
+
+	// ackMu serializes the acknowledgements sent on every tick with those
+	// sent in reply to GETACK
+	ackMu sync.Mutex
+
+	stopped  chan struct{}
+	stopOnce sync.Once
+}
+
+func NewReplica(masterHost, masterPort, listeningPort string, handler Handler) *Replica {
+	return &Replica{
+		masterHost:    masterHost,
+		masterPort:    masterPort,
+		listeningPort: listeningPort,
+		handler:       handler,
+		stopped:       make(chan struct{}),
+	}
+}
+
+func (r *Replica) MasterHost() string {
+	return r.masterHost
+}
+
+func (r *Replica) MasterPort() string {
+	return r.masterPort
+}
+
+// LinkUp reports whether the replica is connected and in sync with its master.
+func (r *Replica) LinkUp() bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.linkUp
+}
+
+func (r *Replica) ReplID() string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.replID
+}
+
+// Offset returns the number of bytes of the replication stream processed.
+func (r *Replica) Offset() int64 {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.offset
+}
+
+// Start keeps the link to the master up in the background, reconnecting
+// after it drops, until Stop is called.
+func (r *Replica) Start() {
+	go func() {
+		for {
+			fmt.Printf("Connecting to master %s:%s\n", r.masterHost, r.masterPort)
+			if err := r.Run(); err != nil {
+				fmt.Printf("Replication error: %v\n", err)
+			} else {
+				fmt.Println("Master closed the replication link")
+			}
+
+			select {
+			case <-r.stopped:
+				return
+			case <-time.After(reconnectDelay):
+			}
+		}
+	}()
+}
+
+// Stop closes the link to the master and prevents any reconnection.
+func (r *Replica) Stop() {
+	r.stopOnce.Do(func() { close(r.stopped) })
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.conn != nil {
+		r.conn.Close()
+	}
+}
+
+// Run connects to the master, performs the handshake and then applies the
+// replication stream until the connection is closed.
+func (r *Replica) Run() error {
+	address := net.JoinHostPort(r.masterHost, r.masterPort)
+	conn, err := net.Dial("tcp", address)
+	if err != nil {
+		return fmt.Errorf("failed to connect to master %s: %w", address, err)
+	}
+	defer conn.Close()
+
+	r.mu.Lock()
+	r.conn = conn
+	r.mu.Unlock()
+
+	select {
+	case <-r.stopped:
+		return nil
+	default:
+	}
+
+	// A resumed stream continues on the database it last selected
+	previous := r.session
+	r.session = session.New(0, conn)
+	r.session.Master = true
+	if previous != nil {
+		r.session.DB = previous.DB
+	}
+	parser := resp.NewParser(conn)
+
+	if err := r.handshake(conn, parser); err != nil {
+		return fmt.Errorf("handshake with master failed: %w", err)
+	}
+
+	if err := r.sync(conn, parser); err != nil {
+		return err
+	}
+
+	r.setLinkUp(true)
+	defer r.setLinkUp(false)
+
+	done := make(chan struct{})
+	defer close(done)
+	go r.ackPeriodically(conn, done)
+
+	for {
+		before := parser.BytesRead()
+		command, err := parser.Parse()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read from master: %w", err)
+		}
+
+		if isGetAck(command) {
+			// The reported offset excludes the GETACK request itself
+			if err := r.ack(conn); err != nil {
+				return fmt.Errorf("failed to acknowledge offset: %w", err)
+			}
+		} else {
+			// Replies to the master are suppressed
+			r.handler.Evaluate(r.session, command)
+		}
+		r.advance(parser.BytesRead() - before)
+	}
+}
+
+// ackPeriodically reports the offset every ackInterval until done is
+// closed, as Redis replicas do, so the master hears from the replica
+// without having to ask.
+func (r *Replica) ackPeriodically(conn net.Conn, done <-chan struct{}) {
+	ticker := time.NewTicker(ackInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			// A failed write also fails the read loop, which ends the link
+			if err := r.ack(conn); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// ack sends REPLCONF ACK with the offset processed so far.
+func (r *Replica) ack(conn net.Conn) error {
+	r.ackMu.Lock()
+	defer r.ackMu.Unlock()
+
+	_, err := conn.Write(encodeCommand("REPLCONF", "ACK", strconv.FormatInt(r.Offset(), 10)))
+	return err
+}
+
+func (r *Replica) handshake(conn net.Conn, parser *resp.Parser) error {
+	steps := []struct {
+		command  []string
+		expected string
+	}{
+		{[]string{"PING"}, "PONG"},
+		{[]string{"REPLCONF", "listening-port", r.listeningPort}, "OK"},
+		{[]string{"REPLCONF", "capa", "psync2"}, "OK"},
+	}
+
+	for _, step := range steps {
+		reply, err := request(conn, parser, step.command...)
+		if err != nil {
+			return err
+		}
+
+		if reply != step.expected {
+			return fmt.Errorf("unexpected reply to %s: %q", step.command[0], reply)
+		}
+	}
+
+	return nil
+}
+
+// sync sends PSYNC, resuming from the last processed offset when a previous
+// link to the same master exists, and loads the snapshot on a full resync.
+func (r *Replica) sync(conn net.Conn, parser *resp.Parser) error {
+	replID, offset := "?", "-1"
+	if previous := r.ReplID(); previous != "" {
+		replID, offset = previous, strconv.FormatInt(r.Offset()+1, 10)
+	}
+
+	reply, err := request(conn, parser, "PSYNC", replID, offset)
+	if err != nil {
+		return fmt.Errorf("PSYNC failed: %w", err)
+	}
+
+	fields := strings.Fields(reply)
+	switch {
+	case len(fields) == 3 && fields[0] == "FULLRESYNC":
+		masterOffset, err := strconv.ParseInt(fields[2], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid FULLRESYNC offset: %q", fields[2])
+		}
+
+		snapshot, _, err := parser.RDBStream()
+		if err != nil {
+			return fmt.Errorf("failed to read RDB from master: %w", err)
+		}
+		if err := r.handler.LoadRDB(snapshot); err != nil {
+			return fmt.Errorf("failed to load RDB from master: %w", err)
+		}
+		// The command stream starts right after the payload
+		if _, err := io.Copy(io.Discard, snapshot); err != nil {
+			return fmt.Errorf("failed to read RDB from master: %w", err)
+		}
+		r.session.DB = 0
+
+		r.mu.Lock()
+		r.replID = fields[1]
+		r.offset = masterOffset
+		r.mu.Unlock()
+	case len(fields) >= 1 && fields[0] == "CONTINUE":
+		if len(fields) == 2 {
+			r.mu.Lock()
+			r.replID = fields[1]
+			r.mu.Unlock()
+		}
+	default:
+		return fmt.Errorf("unexpected reply to PSYNC: %q", reply)
+	}
+
+	return nil
+}
+
+func (r *Replica) setLinkUp(up bool) {
+	r.mu.Lock()
+	r.linkUp = up
+	r.mu.Unlock()
+}
+
+func (r *Replica) advance(bytes int64) {
+	r.mu.Lock()
+	r.offset += bytes
+	r.mu.Unlock()
+}
+
+func isGetAck(command resp.Value) bool {
+	array, ok := command.(*resp.Array)
+	if !ok {
+		return false
+	}
+
+	items := array.Items()
+	return len(items) >= 2 &&
+		strings.EqualFold(items[0].String(), "REPLCONF") &&
+		strings.EqualFold(items[1].String(), "GETACK")
+}
+
+// request sends a command to the master and returns its simple string reply.
+func request(conn net.Conn, parser *resp.Parser, args ...string) (string, error) {
+	if _, err := conn.Write(encodeCommand(args...)); err != nil {
+		return "", err
+	}
+
+	reply, err := parser.Parse()
+	if err != nil {
+		return "", err
+	}
+
+	if _, ok := reply.(*resp.SimpleString); !ok {
+		return "", fmt.Errorf("unexpected reply to %s: %q", args[0], reply.String())
+	}
+
+	return reply.String(), nil
+}
+
+func encodeCommand(args ...string) []byte {
+	items := make([]resp.Value, len(args))
+	for i, arg := range args {
+		items[i] = resp.NewBulkString(arg)
+	}
+	return resp.NewArray(items).Serialize()
+}
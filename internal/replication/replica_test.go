@@ -0,0 +1,167 @@
+package replication
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/md-talim/codecrafters-redis-go/internal/resp"
+	"github.com/md-talim/codecrafters-redis-go/internal/session"
+)
+
+type recordingHandler struct {
+	snapshot []byte
+	commands []string
+}
+
+func (h *recordingHandler) LoadRDB(snapshot io.Reader) error {
+	var err error
+	h.snapshot, err = io.ReadAll(snapshot)
+	return err
+}
+
+func (h *recordingHandler) Evaluate(s *session.Session, command resp.Value) resp.Value {
+	h.commands = append(h.commands, command.String())
+	return resp.NewSimpleString("OK")
+}
+
+func TestReplicaHandshakeAndStream(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer listener.Close()
+
+	snapshot := []byte("REDIS0011\xff")
+	received := make(chan []string, 1)
+	acked := make(chan string, 1)
+
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		parser := resp.NewParser(conn)
+		replies := []string{"+PONG\r\n", "+OK\r\n", "+OK\r\n", "+FULLRESYNC abc 0\r\n"}
+		var commands []string
+		for _, reply := range replies {
+			command, err := parser.Parse()
+			if err != nil {
+				return
+			}
+			commands = append(commands, command.String())
+			conn.Write([]byte(reply))
+		}
+		received <- commands
+
+		conn.Write(fmt.Appendf(nil, "$%d\r\n%s", len(snapshot), snapshot))
+		conn.Write(encodeCommand("SET", "foo", "bar"))
+		conn.Write(encodeCommand("REPLCONF", "GETACK", "*"))
+
+		ack, err := parser.Parse()
+		if err != nil {
+			return
+		}
+		acked <- ack.String()
+	}()
+
+	handler := &recordingHandler{}
+	_, port, _ := net.SplitHostPort(listener.Addr().String())
+	replica := NewReplica("127.0.0.1", port, "6380", handler)
+
+	if err := replica.Run(); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	expected := []string{"PING", "REPLCONF listening-port 6380", "REPLCONF capa psync2", "PSYNC ? -1"}
+	commands := <-received
+	if fmt.Sprint(commands) != fmt.Sprint(expected) {
+		t.Errorf("Expected handshake %q, got %q", expected, commands)
+	}
+
+	if !bytes.Equal(handler.snapshot, snapshot) {
+		t.Errorf("Expected snapshot %q, got %q", snapshot, handler.snapshot)
+	}
+
+	expectedAck := fmt.Sprintf("REPLCONF ACK %d", len(encodeCommand("SET", "foo", "bar")))
+	if ack := <-acked; ack != expectedAck {
+		t.Errorf("Expected %q, got %q", expectedAck, ack)
+	}
+
+	if len(handler.commands) != 1 || handler.commands[0] != "SET foo bar" {
+		t.Errorf("Expected [SET foo bar], got %q", handler.commands)
+	}
+
+	if replica.ReplID() != "abc" {
+		t.Errorf("Expected replid 'abc', got %q", replica.ReplID())
+	}
+
+	expectedOffset := int64(len(encodeCommand("SET", "foo", "bar")) + len(encodeCommand("REPLCONF", "GETACK", "*")))
+	if replica.Offset() != expectedOffset {
+		t.Errorf("Expected offset %d, got %d", expectedOffset, replica.Offset())
+	}
+}
+
+func TestReplicaAcksWithoutGetAck(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer listener.Close()
+
+	master := NewMaster(1024)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		s := session.New(1, conn)
+		parser := resp.NewParser(conn)
+		for _, reply := range []string{"+PONG\r\n", "+OK\r\n", "+OK\r\n"} {
+			if _, err := parser.Parse(); err != nil {
+				return
+			}
+			conn.Write([]byte(reply))
+		}
+		if _, err := parser.Parse(); err != nil { // PSYNC
+			return
+		}
+		go master.FullResync(s, func() ([]byte, error) { return []byte("REDIS0011\xff"), nil })
+
+		for {
+			command, err := parser.Parse()
+			if err != nil {
+				return
+			}
+			items := command.(*resp.Array).Items()
+			if len(items) == 3 && strings.EqualFold(items[1].String(), "ACK") {
+				offset, _ := strconv.ParseInt(items[2].String(), 10, 64)
+				master.Ack(s, offset)
+			}
+		}
+	}()
+
+	_, port, _ := net.SplitHostPort(listener.Addr().String())
+	replica := NewReplica("127.0.0.1", port, "6380", &recordingHandler{})
+	go replica.Run()
+	defer replica.Stop()
+
+	// Without WAIT the master never sends GETACK, yet the lag stays low
+	time.Sleep(2500 * time.Millisecond)
+	replicas := master.Replicas()
+	if len(replicas) != 1 {
+		t.Fatalf("Expected 1 replica, got %d", len(replicas))
+	}
+	if lag := int(time.Since(replicas[0].LastAck).Seconds()); lag > 1 {
+		t.Errorf("Expected a lag of 0 or 1 seconds, got %d", lag)
+	}
+}
@@ -0,0 +1,194 @@
+package resp
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// maxInlineLength is the longest inline command accepted, as in Redis.
+const maxInlineLength = 64 * 1024
+
+var (
+	errUnbalancedQuotes = errors.New("unbalanced quotes in request")
+	errLineTooLong      = errors.New("line too long")
+)
+
+// ParseCommand reads the next command sent by a client. Besides multibulk
+// arrays it accepts inline commands, a line of space separated arguments as
+// typed into telnet or netcat, which it returns as an array of bulk strings.
+// Empty lines are skipped. Lines are read at most maxInlineLength bytes at a
+// time, so a client cannot make the server buffer an endless line.
+func (p *Parser) ParseCommand() (Value, error) {
+	for {
+		line, err := p.readLineLimited(maxInlineLength)
+		if errors.Is(err, errLineTooLong) {
+			return nil, fmt.Errorf("%w: too big inline request", ErrProtocol)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if strings.HasPrefix(line, "*") {
+			return p.parseMultibulk(line)
+		}
+
+		args, err := splitArgs(line)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrProtocol, err)
+		}
+		if len(args) == 0 {
+			continue
+		}
+
+		items := make([]Value, len(args))
+		for i, arg := range args {
+			items[i] = NewBulkString(arg)
+		}
+		return NewArray(items), nil
+	}
+}
+
+// parseMultibulk reads the elements of a command sent as an array, which
+// must all be bulk strings.
+func (p *Parser) parseMultibulk(line string) (Value, error) {
+	count, err := parseLength(line, maxAggregateLength, true)
+	if err != nil {
+		return nil, err
+	}
+	if count == -1 {
+		return NewNullArray(), nil
+	}
+
+	items := make([]Value, 0, min(count, maxPreallocatedItems))
+	for range count {
+		line, err := p.readLineLimited(maxInlineLength)
+		if errors.Is(err, errLineTooLong) {
+			return nil, fmt.Errorf("%w: too big bulk count string", ErrProtocol)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !strings.HasPrefix(line, "$") {
+			return nil, fmt.Errorf("%w: expected '$', got '%s'", ErrProtocol, line[:min(len(line), 1)])
+		}
+
+		length, err := parseLength(line, maxBulkLength, false)
+		if err != nil {
+			return nil, err
+		}
+		bulk, err := p.parseBulk(length)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, NewBulkBytes(bulk))
+	}
+	return NewArray(items), nil
+}
+
+// splitArgs splits an inline command into arguments following the rules of
+// sdssplitargs in Redis. Arguments are separated by whitespace and may be
+// quoted. Double quoted arguments support the escapes \n, \r, \t, \b, \a
+// and \xHH, and a backslash before any other character stands for that
+// character. Single quoted arguments only support \'. A closing quote must
+// be followed by whitespace or the end of the line.
+func splitArgs(line string) ([]string, error) {
+	var args []string
+	i := 0
+	for {
+		for i < len(line) && isSpace(line[i]) {
+			i++
+		}
+		if i == len(line) {
+			return args, nil
+		}
+
+		var arg strings.Builder
+		inDoubleQuotes, inSingleQuotes := false, false
+		for done := false; !done; {
+			switch {
+			case inDoubleQuotes:
+				if i == len(line) {
+					return nil, errUnbalancedQuotes
+				}
+				switch {
+				case line[i] == '\\' && i+3 < len(line) && line[i+1] == 'x' && isHexDigit(line[i+2]) && isHexDigit(line[i+3]):
+					value, _ := strconv.ParseUint(line[i+2:i+4], 16, 8)
+					arg.WriteByte(byte(value))
+					i += 3
+				case line[i] == '\\' && i+1 < len(line):
+					i++
+					arg.WriteByte(unescape(line[i]))
+				case line[i] == '"':
+					// The closing quote must be followed by a space or nothing
+					if i+1 < len(line) && !isSpace(line[i+1]) {
+						return nil, errUnbalancedQuotes
+					}
+					done = true
+				default:
+					arg.WriteByte(line[i])
+				}
+			case inSingleQuotes:
+				if i == len(line) {
+					return nil, errUnbalancedQuotes
+				}
+				switch {
+				case line[i] == '\\' && i+1 < len(line) && line[i+1] == '\'':
+					i++
+					arg.WriteByte('\'')
+				case line[i] == '\'':
+					if i+1 < len(line) && !isSpace(line[i+1]) {
+						return nil, errUnbalancedQuotes
+					}
+					done = true
+				default:
+					arg.WriteByte(line[i])
+				}
+			default:
+				if i == len(line) {
+					done = true
+					continue
+				}
+				switch line[i] {
+				case ' ', '\n', '\r', '\t', 0:
+					done = true
+				case '"':
+					inDoubleQuotes = true
+				case '\'':
+					inSingleQuotes = true
+				default:
+					arg.WriteByte(line[i])
+				}
+			}
+			if i < len(line) {
+				i++
+			}
+		}
+		args = append(args, arg.String())
+	}
+}
+
+func unescape(c byte) byte {
+	switch c {
+	case 'n':
+		return '\n'
+	case 'r':
+		return '\r'
+	case 't':
+		return '\t'
+	case 'b':
+		return '\b'
+	case 'a':
+		return '\a'
+	default:
+		return c
+	}
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\n' || c == '\r' || c == '\t' || c == '\v' || c == '\f' || c == 0
+}
+
+func isHexDigit(c byte) bool {
+	return (c >= '0' && c <= '9') || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
@@ -0,0 +1,116 @@
+package resp
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSplitArgs(t *testing.T) {
+	tests := []struct {
+		line     string
+		expected []string
+	}{
+		{"PING", []string{"PING"}},
+		{"  SET   a\tb  ", []string{"SET", "a", "b"}},
+		{`SET key "hello world"`, []string{"SET", "key", "hello world"}},
+		{`SET key "line\nbreak \"quoted\" \x41\x7a"`, []string{"SET", "key", "line\nbreak \"quoted\" Az"}},
+		{`SET key 'it\'s raw \n'`, []string{"SET", "key", `it's raw \n`}},
+		{`ECHO ""`, []string{"ECHO", ""}},
+		{`ECHO pre"fix"`, []string{"ECHO", "prefix"}},
+		{"", nil},
+		{"   ", nil},
+	}
+
+	for _, test := range tests {
+		args, err := splitArgs(test.line)
+		if err != nil {
+			t.Errorf("splitArgs(%q) failed: %v", test.line, err)
+			continue
+		}
+		if !reflect.DeepEqual(args, test.expected) {
+			t.Errorf("splitArgs(%q): expected %q, got %q", test.line, test.expected, args)
+		}
+	}
+}
+
+func TestSplitArgsUnbalancedQuotes(t *testing.T) {
+	for _, line := range []string{`SET "a`, `SET 'a`, `SET "a"b`, `SET 'a'b`, `SET "a\"`} {
+		if _, err := splitArgs(line); !errors.Is(err, errUnbalancedQuotes) {
+			t.Errorf("splitArgs(%q): expected unbalanced quotes, got %v", line, err)
+		}
+	}
+}
+
+func TestParseCommandInline(t *testing.T) {
+	input := "PING\r\n\r\n*2\r\n$4\r\nECHO\r\n$2\r\nhi\r\nSET a \"b c\"\n"
+	parser := NewParser(strings.NewReader(input))
+
+	expected := [][]string{{"PING"}, {"ECHO", "hi"}, {"SET", "a", "b c"}}
+	for _, args := range expected {
+		command, err := parser.ParseCommand()
+		if err != nil {
+			t.Fatalf("ParseCommand failed: %v", err)
+		}
+
+		array, ok := command.(*Array)
+		if !ok {
+			t.Fatalf("Expected Array, got %T", command)
+		}
+		var actual []string
+		for _, item := range array.Items() {
+			if _, isBulk := item.(*BulkString); !isBulk {
+				t.Errorf("Expected bulk string arguments, got %T", item)
+			}
+			actual = append(actual, item.String())
+		}
+		if !reflect.DeepEqual(actual, args) {
+			t.Errorf("Expected %q, got %q", args, actual)
+		}
+	}
+
+	if _, err := parser.ParseCommand(); err != io.EOF {
+		t.Errorf("Expected io.EOF, got %v", err)
+	}
+}
+
+func TestParseCommandInlineErrors(t *testing.T) {
+	_, err := NewParser(strings.NewReader("SET a \"b\r\n")).ParseCommand()
+	if !errors.Is(err, ErrProtocol) || err.Error() != "Protocol error: unbalanced quotes in request" {
+		t.Errorf("Expected an unbalanced quotes protocol error, got %v", err)
+	}
+
+	_, err = NewParser(strings.NewReader(strings.Repeat("a", maxInlineLength+1) + "\r\n")).ParseCommand()
+	if !errors.Is(err, ErrProtocol) {
+		t.Errorf("Expected a protocol error for a too big inline request, got %v", err)
+	}
+
+	// A line that never ends fails once it is too long, not at the end of
+	// the input
+	_, err = NewParser(strings.NewReader(strings.Repeat("a", 4*maxInlineLength))).ParseCommand()
+	if !errors.Is(err, ErrProtocol) {
+		t.Errorf("Expected a protocol error for an endless inline request, got %v", err)
+	}
+}
+
+func TestParseCommandMultibulkErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"*2\r\n$4\r\nECHO\r\n:1\r\n", "Protocol error: expected '$', got ':'"},
+		{"*1\r\n*1\r\n$1\r\na\r\n", "Protocol error: expected '$', got '*'"},
+		{"*1\r\n\r\n", "Protocol error: expected '$', got ''"},
+		{"*1\r\n$-1\r\n", `Protocol error: invalid length "-1"`},
+		{"*1\r\n$" + strings.Repeat("1", 2*maxInlineLength), "Protocol error: too big bulk count string"},
+	}
+
+	for _, test := range tests {
+		_, err := NewParser(strings.NewReader(test.input)).ParseCommand()
+		if !errors.Is(err, ErrProtocol) || err.Error() != test.expected {
+			t.Errorf("ParseCommand(%q): expected %q, got %v", test.input[:min(len(test.input), 32)], test.expected, err)
+		}
+	}
+}
@@ -1,6 +1,12 @@
 package resp
 
 import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 )
@@ -68,3 +74,167 @@ func TestParseArray(t *testing.T) {
 		t.Errorf("Expected 'hello', got %q", items[1].String())
 	}
 }
+
+func TestParserBytesRead(t *testing.T) {
+	input := "*3\r\n$3\r\nSET\r\n$3\r\nfoo\r\n$3\r\nbar\r\n+OK\r\n"
+	parser := NewParser(strings.NewReader(input))
+
+	if _, err := parser.Parse(); err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	if parser.BytesRead() != 31 {
+		t.Errorf("Expected 31 bytes read, got %d", parser.BytesRead())
+	}
+
+	if _, err := parser.Parse(); err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	if parser.BytesRead() != int64(len(input)) {
+		t.Errorf("Expected %d bytes read, got %d", len(input), parser.BytesRead())
+	}
+}
+
+func TestParserRDBStream(t *testing.T) {
+	input := "$10\r\nREDIS0011\xff*1\r\n$4\r\nPING\r\n"
+	parser := NewParser(strings.NewReader(input))
+
+	stream, length, err := parser.RDBStream()
+	if err != nil {
+		t.Fatalf("RDBStream failed: %v", err)
+	}
+	if length != 10 {
+		t.Errorf("Expected a length of 10, got %d", length)
+	}
+
+	payload, err := io.ReadAll(stream)
+	if err != nil || string(payload) != "REDIS0011\xff" {
+		t.Fatalf("Expected the payload, got %q, %v", payload, err)
+	}
+
+	command, err := parser.Parse()
+	if err != nil || command.String() != "PING" {
+		t.Errorf("Expected the stream to resume after the payload, got %v, %v", command, err)
+	}
+	if parser.BytesRead() != int64(len(input)) {
+		t.Errorf("Expected %d bytes read, got %d", len(input), parser.BytesRead())
+	}
+}
+
+func TestParserRDBStreamTruncated(t *testing.T) {
+	stream, _, err := NewParser(strings.NewReader("$10\r\nREDIS")).RDBStream()
+	if err != nil {
+		t.Fatalf("RDBStream failed: %v", err)
+	}
+	if _, err := io.ReadAll(stream); err != io.ErrUnexpectedEOF {
+		t.Errorf("Expected io.ErrUnexpectedEOF, got %v", err)
+	}
+}
+
+// TestParseGoldenFixtures parses each fixture in testdata, checks the type
+// and text of the value and that it serializes back to the same bytes.
+func TestParseGoldenFixtures(t *testing.T) {
+	tests := []struct {
+		fixture  string
+		expected Value
+		text     string
+	}{
+		{"simple_string", &SimpleString{}, "OK"},
+		{"simple_error", &SimpleError{}, "ERR unknown command 'foo'"},
+		{"integer", &Integer{}, "-42"},
+		{"bulk_string", &BulkString{}, "hello\r\nworld\x00"},
+		{"empty_bulk_string", &BulkString{}, ""},
+		{"null_bulk_string", &BulkString{}, ""},
+		{"array", &Array{}, "1 nested  end"},
+		{"empty_array", &Array{}, ""},
+		{"null_array", &Array{}, ""},
+		{"null", &Null{}, ""},
+		{"double", &Double{}, "3.14"},
+		{"double_inf", &Double{}, "-inf"},
+		{"boolean", &Boolean{}, "false"},
+		{"big_number", &BigNumber{}, "-3492890328409238509324850943850943825024385"},
+		{"blob_error", &BlobError{}, "SYNTAX invalid syntax"},
+		{"verbatim", &Verbatim{}, "Some string"},
+		{"map", &Map{}, "first 1 second 2"},
+		{"set", &Set{}, "orange true 100"},
+		{"push", &Push{}, "message channel hello"},
+		{"attribute", &Attribute{}, "2039123 9543892"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.fixture, func(t *testing.T) {
+			data, err := os.ReadFile(filepath.Join("testdata", test.fixture+".resp"))
+			if err != nil {
+				t.Fatalf("Failed to read fixture: %v", err)
+			}
+
+			parser := NewParser(bytes.NewReader(data))
+			value, err := parser.Parse()
+			if err != nil {
+				t.Fatalf("Parse failed: %v", err)
+			}
+
+			if reflect.TypeOf(value) != reflect.TypeOf(test.expected) {
+				t.Errorf("Expected %T, got %T", test.expected, value)
+			}
+			if test.text != "" && value.String() != test.text {
+				t.Errorf("Expected %q, got %q", test.text, value.String())
+			}
+			if serialized := value.Serialize(); !bytes.Equal(serialized, data) {
+				t.Errorf("Expected to serialize back to %q, got %q", data, serialized)
+			}
+			if parser.BytesRead() != int64(len(data)) {
+				t.Errorf("Expected %d bytes read, got %d", len(data), parser.BytesRead())
+			}
+		})
+	}
+}
+
+func TestParseNulls(t *testing.T) {
+	value, err := NewParser(strings.NewReader("$-1\r\n")).Parse()
+	if bulk, ok := value.(*BulkString); err != nil || !ok || !bulk.IsNull() {
+		t.Errorf("Expected a null bulk string, got %v, %v", value, err)
+	}
+
+	value, err = NewParser(strings.NewReader("*-1\r\n")).Parse()
+	if array, ok := value.(*Array); err != nil || !ok || !array.IsNull() {
+		t.Errorf("Expected a null array, got %v, %v", value, err)
+	}
+}
+
+func TestParseMalformed(t *testing.T) {
+	inputs := []string{
+		"\r\n",
+		"?foo\r\n",
+		":12a\r\n",
+		"$-2\r\n",
+		"$2\r\nhiXY",
+		"*-2\r\n",
+		"*x\r\n",
+		"%-1\r\n",
+		"~-1\r\n",
+		"_x\r\n",
+		",1.2.3\r\n",
+		"#x\r\n",
+		"(12x\r\n",
+		"=3\r\ntxt\r\n",
+		"$536870913\r\n",
+	}
+
+	for _, input := range inputs {
+		_, err := NewParser(strings.NewReader(input)).Parse()
+		if !errors.Is(err, ErrProtocol) {
+			t.Errorf("Parse(%q): expected a protocol error, got %v", input, err)
+		}
+	}
+}
+
+func TestParseTruncated(t *testing.T) {
+	for _, input := range []string{"$5\r\nhel", "*2\r\n:1\r\n", "%1\r\n+key\r\n", "$3\r\nfoo"} {
+		_, err := NewParser(strings.NewReader(input)).Parse()
+		if !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+			t.Errorf("Parse(%q): expected an unexpected end of input, got %v", input, err)
+		}
+	}
+}
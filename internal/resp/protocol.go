@@ -0,0 +1,101 @@
+package resp
+
+import "strings"
+
+// Protocol versions a client can negotiate with HELLO.
+const (
+	RESP2 = 2
+	RESP3 = 3
+)
+
+// Encode serializes a reply for a client speaking the given protocol.
+// Commands reply with RESP3 types, which RESP2 clients receive as their
+// RESP2 equivalents.
+func Encode(value Value, protocol int) []byte {
+	if protocol == RESP3 {
+		return ToRESP3(value).Serialize()
+	}
+	return ToRESP2(value).Serialize()
+}
+
+// ToRESP2 converts RESP3 types to the types RESP2 clients expect: maps are
+// flattened to arrays of keys and values, sets and pushes become arrays,
+// doubles, big numbers and verbatim strings become bulk strings, blob errors
+// become simple errors, booleans become integers and attributes are dropped.
+func ToRESP2(value Value) Value {
+	switch value := value.(type) {
+	case *Null:
+		return NewNullBulkString()
+	case *Double:
+		return NewBulkString(formatDouble(value.value))
+	case *Boolean:
+		if value.value {
+			return NewInteger("1")
+		}
+		return NewInteger("0")
+	case *BigNumber:
+		return NewBulkString(value.value)
+	case *Verbatim:
+		return NewBulkString(value.text)
+	case *BlobError:
+		return NewSimpleError(strings.NewReplacer("\r", " ", "\n", " ").Replace(value.value))
+	case *Array:
+		if value.null {
+			return value
+		}
+		return NewArray(convertAll(value.items, ToRESP2))
+	case *Map:
+		return NewArray(convertAll(flattenEntries(value.entries), ToRESP2))
+	case *Set:
+		return NewArray(convertAll(value.items, ToRESP2))
+	case *Push:
+		return NewArray(convertAll(value.items, ToRESP2))
+	case *Attribute:
+		return ToRESP2(value.value)
+	default:
+		return value
+	}
+}
+
+// ToRESP3 converts the RESP2 null bulk string and null array to the RESP3
+// null, including within aggregates.
+func ToRESP3(value Value) Value {
+	switch value := value.(type) {
+	case *BulkString:
+		if value.null {
+			return NewNull()
+		}
+		return value
+	case *Array:
+		if value.null {
+			return NewNull()
+		}
+		return NewArray(convertAll(value.items, ToRESP3))
+	case *Map:
+		return NewMap(convertEntries(value.entries, ToRESP3))
+	case *Set:
+		return NewSet(convertAll(value.items, ToRESP3))
+	case *Push:
+		return NewPush(convertAll(value.items, ToRESP3))
+	case *Attribute:
+		return NewAttribute(convertEntries(value.entries, ToRESP3), ToRESP3(value.value))
+	default:
+		return value
+	}
+}
+
+func convertAll(items []Value, convert func(Value) Value) []Value {
+	converted := make([]Value, len(items))
+	for i, item := range items {
+		converted[i] = convert(item)
+	}
+	return converted
+}
+
+func convertEntries(entries []MapEntry, convert func(Value) Value) []MapEntry {
+	converted := make([]MapEntry, len(entries))
+	for i, entry := range entries {
+		converted[i] = MapEntry{convert(entry.Key), convert(entry.Value)}
+	}
+	return converted
+}
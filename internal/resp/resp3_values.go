@@ -0,0 +1,237 @@
+package resp
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// serializeAggregate encodes an aggregate type as its prefix, the number of
+// elements and the elements themselves.
+func serializeAggregate(prefix byte, count int, items []Value) []byte {
+	result := fmt.Appendf(nil, "%c%d%s", prefix, count, CRLF)
+	for _, item := range items {
+		result = append(result, item.Serialize()...)
+	}
+	return result
+}
+
+func joinStrings(items []Value) string {
+	parts := make([]string, len(items))
+	for i, item := range items {
+		parts[i] = item.String()
+	}
+	return strings.Join(parts, " ")
+}
+
+type Null struct{}
+
+func NewNull() *Null {
+	return &Null{}
+}
+
+func (n *Null) String() string { return "" }
+
+func (n *Null) Serialize() []byte {
+	return []byte("_" + CRLF)
+}
+
+type Double struct {
+	value float64
+}
+
+func NewDouble(value float64) *Double {
+	return &Double{value}
+}
+
+func (d *Double) Float() float64 { return d.value }
+
+func (d *Double) String() string { return formatDouble(d.value) }
+
+func (d *Double) Serialize() []byte {
+	return fmt.Appendf(nil, ",%s%s", formatDouble(d.value), CRLF)
+}
+
+// formatDouble formats a double the way Redis replies with it, using the
+// shortest representation that parses back to the same value.
+func formatDouble(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "inf"
+	case math.IsInf(value, -1):
+		return "-inf"
+	case math.IsNaN(value):
+		return "nan"
+	default:
+		return strconv.FormatFloat(value, 'g', -1, 64)
+	}
+}
+
+type Boolean struct {
+	value bool
+}
+
+func NewBoolean(value bool) *Boolean {
+	return &Boolean{value}
+}
+
+func (b *Boolean) Bool() bool { return b.value }
+
+func (b *Boolean) String() string { return strconv.FormatBool(b.value) }
+
+func (b *Boolean) Serialize() []byte {
+	if b.value {
+		return []byte("#t" + CRLF)
+	}
+	return []byte("#f" + CRLF)
+}
+
+type BigNumber struct {
+	value string
+}
+
+func NewBigNumber(value string) *BigNumber {
+	return &BigNumber{value}
+}
+
+func (n *BigNumber) String() string { return n.value }
+
+func (n *BigNumber) Serialize() []byte {
+	return fmt.Appendf(nil, "(%s%s", n.value, CRLF)
+}
+
+// BlobError is an error whose description may contain any bytes, including
+// newlines.
+type BlobError struct {
+	value string
+}
+
+func NewBlobError(value string) *BlobError {
+	return &BlobError{value}
+}
+
+func (e *BlobError) String() string { return e.value }
+
+func (e *BlobError) Serialize() []byte {
+	return fmt.Appendf(nil, "!%d%s%s%s", len(e.value), CRLF, e.value, CRLF)
+}
+
+// Verbatim is a string with a three letter format, such as "txt" or "mkd",
+// that clients may use to decide how to display it.
+type Verbatim struct {
+	format string
+	text   string
+}
+
+func NewVerbatim(format, text string) *Verbatim {
+	return &Verbatim{format, text}
+}
+
+func (v *Verbatim) Format() string { return v.format }
+
+func (v *Verbatim) String() string { return v.text }
+
+func (v *Verbatim) Serialize() []byte {
+	return fmt.Appendf(nil, "=%d%s%s:%s%s", len(v.format)+1+len(v.text), CRLF, v.format, v.text, CRLF)
+}
+
+// MapEntry is a key and value pair of a Map or Attribute.
+type MapEntry struct {
+	Key   Value
+	Value Value
+}
+
+func flattenEntries(entries []MapEntry) []Value {
+	items := make([]Value, 0, 2*len(entries))
+	for _, entry := range entries {
+		items = append(items, entry.Key, entry.Value)
+	}
+	return items
+}
+
+// Map is an ordered sequence of key and value pairs.
+type Map struct {
+	entries []MapEntry
+}
+
+func NewMap(entries []MapEntry) *Map {
+	return &Map{entries}
+}
+
+func (m *Map) Entries() []MapEntry {
+	return m.entries
+}
+
+func (m *Map) String() string {
+	return joinStrings(flattenEntries(m.entries))
+}
+
+func (m *Map) Serialize() []byte {
+	return serializeAggregate('%', len(m.entries), flattenEntries(m.entries))
+}
+
+// Set is an unordered collection of distinct values.
+type Set struct {
+	items []Value
+}
+
+func NewSet(items []Value) *Set {
+	return &Set{items}
+}
+
+func (s *Set) Items() []Value {
+	return s.items
+}
+
+func (s *Set) String() string { return joinStrings(s.items) }
+
+func (s *Set) Serialize() []byte {
+	return serializeAggregate('~', len(s.items), s.items)
+}
+
+// Push is out of band data sent to a client outside of the replies to its
+// commands.
+type Push struct {
+	items []Value
+}
+
+func NewPush(items []Value) *Push {
+	return &Push{items}
+}
+
+func (p *Push) Items() []Value {
+	return p.items
+}
+
+func (p *Push) String() string { return joinStrings(p.items) }
+
+func (p *Push) Serialize() []byte {
+	return serializeAggregate('>', len(p.items), p.items)
+}
+
+// Attribute carries auxiliary key and value pairs ahead of the value they
+// describe. Clients that do not understand them only see the value.
+type Attribute struct {
+	entries []MapEntry
+	value   Value
+}
+
+func NewAttribute(entries []MapEntry, value Value) *Attribute {
+	return &Attribute{entries, value}
+}
+
+func (a *Attribute) Entries() []MapEntry {
+	return a.entries
+}
+
+func (a *Attribute) Value() Value {
+	return a.value
+}
+
+func (a *Attribute) String() string { return a.value.String() }
+
+func (a *Attribute) Serialize() []byte {
+	result := serializeAggregate('|', len(a.entries), flattenEntries(a.entries))
+	return append(result, a.value.Serialize()...)
+}
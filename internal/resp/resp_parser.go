@@ -3,82 +3,415 @@ package resp
 import (
 	"bufio"
 	"errors"
+	"fmt"
 	"io"
+	"math"
+	"math/big"
 	"strconv"
 	"strings"
 )
 
 const CRLF string = "\r\n"
 
+const (
+	// maxBulkLength is the longest bulk string accepted, as in Redis.
+	maxBulkLength = 512 * 1024 * 1024
+
+	// maxAggregateLength is the most elements accepted in an aggregate.
+	maxAggregateLength = 1<<31 - 1
+
+	// maxPreallocatedItems bounds the memory reserved for an aggregate
+	// before its elements have been read.
+	maxPreallocatedItems = 1024
+)
+
+// ErrProtocol is wrapped by the errors returned for malformed input. It is
+// worded like the errors Redis replies with before closing the connection.
+var ErrProtocol = errors.New("Protocol error")
+
 type Parser struct {
-	reader *bufio.Reader
+	reader    *bufio.Reader
+	bytesRead int64
 }
 
 func NewParser(reader io.Reader) *Parser {
 	return &Parser{reader: bufio.NewReader(reader)}
 }
 
+// Parse reads the next value of any RESP2 or RESP3 type. Malformed input is
+// reported with an error wrapping ErrProtocol.
 func (p *Parser) Parse() (Value, error) {
 	line, err := p.readLine()
 	if err != nil {
 		return nil, err
 	}
+	if len(line) == 0 {
+		return nil, fmt.Errorf("%w: empty line", ErrProtocol)
+	}
 
 	switch line[0] {
 	case '*':
 		return p.parseArray(line)
 	case '+':
 		return p.parseSimpleString(line)
+	case '-':
+		return NewSimpleError(line[1:]), nil
+	case ':':
+		return p.parseInteger(line)
 	case '$':
 		return p.parseBulkString(line)
+	case '_':
+		return p.parseNull(line)
+	case ',':
+		return p.parseDouble(line)
+	case '#':
+		return p.parseBoolean(line)
+	case '(':
+		return p.parseBigNumber(line)
+	case '!':
+		return p.parseBlobError(line)
+	case '=':
+		return p.parseVerbatim(line)
+	case '%':
+		return p.parseMap(line)
+	case '~':
+		return p.parseSet(line)
+	case '>':
+		return p.parsePush(line)
+	case '|':
+		return p.parseAttribute(line)
 	default:
-		return nil, errors.New("unknown RESP type")
+		return nil, fmt.Errorf("%w: unknown RESP type %q", ErrProtocol, line[0])
+	}
+}
+
+// ReadRDB reads an RDB snapshot sent by a master after +FULLRESYNC. It is
+// framed like a bulk string but without the trailing CRLF.
+func (p *Parser) ReadRDB() ([]byte, error) {
+	stream, length, err := p.RDBStream()
+	if err != nil {
+		return nil, err
+	}
+
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(stream, payload); err != nil {
+		return nil, err
+	}
+	return payload, nil
+}
+
+// RDBStream reads the length of an RDB snapshot sent by a master and returns
+// a reader over its payload, so it can be loaded without holding all of it
+// in memory. The payload must be read to the end before parsing resumes.
+func (p *Parser) RDBStream() (io.Reader, int64, error) {
+	line, err := p.readLine()
+	if err != nil {
+		return nil, 0, err
 	}
+
+	if len(line) == 0 || line[0] != '$' {
+		return nil, 0, errors.New("expected RDB payload")
+	}
+
+	length, err := strconv.ParseInt(line[1:], 10, 64)
+	if err != nil || length < 0 {
+		return nil, 0, fmt.Errorf("invalid RDB payload length: %q", line[1:])
+	}
+
+	return &payloadReader{parser: p, remaining: length}, length, nil
+}
+
+// payloadReader reads at most remaining bytes from the parser's stream,
+// counting them as consumed.
+type payloadReader struct {
+	parser    *Parser
+	remaining int64
+}
+
+func (r *payloadReader) Read(buffer []byte) (int, error) {
+	if r.remaining <= 0 {
+		return 0, io.EOF
+	}
+	if int64(len(buffer)) > r.remaining {
+		buffer = buffer[:r.remaining]
+	}
+
+	n, err := r.parser.reader.Read(buffer)
+	r.parser.bytesRead += int64(n)
+	r.remaining -= int64(n)
+	if err == io.EOF && r.remaining > 0 {
+		err = io.ErrUnexpectedEOF
+	}
+	return n, err
+}
+
+// BytesRead returns the number of bytes consumed by the values parsed so far.
+func (p *Parser) BytesRead() int64 {
+	return p.bytesRead
 }
 
 func (p *Parser) readLine() (string, error) {
 	line, err := p.reader.ReadString('\n')
+	p.bytesRead += int64(len(line))
 	if err != nil {
 		return "", err
 	}
 	return strings.TrimRight(line, CRLF), nil
 }
 
-func (p *Parser) parseArray(line string) (Value, error) {
-	count, err := strconv.Atoi(line[1:])
-	if err != nil {
+// readLineLimited reads a line like readLine, but returns errLineTooLong
+// once more than limit bytes arrive before the line ends.
+func (p *Parser) readLineLimited(limit int) (string, error) {
+	var line []byte
+	for {
+		chunk, err := p.reader.ReadSlice('\n')
+		p.bytesRead += int64(len(chunk))
+		line = append(line, chunk...)
+		if len(line) > limit+len(CRLF) {
+			return "", errLineTooLong
+		}
+		if err == bufio.ErrBufferFull {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimRight(string(line), CRLF), nil
+	}
+}
+
+func (p *Parser) readFull(buffer []byte) error {
+	n, err := io.ReadFull(p.reader, buffer)
+	p.bytesRead += int64(n)
+	return err
+}
+
+// readCRLF consumes the CRLF that terminates a bulk payload.
+func (p *Parser) readCRLF() error {
+	var terminator [2]byte
+	if err := p.readFull(terminator[:]); err != nil {
+		return err
+	}
+	if string(terminator[:]) != CRLF {
+		return fmt.Errorf("%w: expected CRLF after bulk data", ErrProtocol)
+	}
+	return nil
+}
+
+// parseLength parses the length of a bulk or aggregate type. A length of -1
+// denotes a RESP2 null and is only accepted when nullable is set.
+func parseLength(line string, limit int, nullable bool) (int, error) {
+	length, err := strconv.Atoi(line[1:])
+	if err != nil || length < -1 || (length == -1 && !nullable) || length > limit {
+		return 0, fmt.Errorf("%w: invalid length %q", ErrProtocol, line[1:])
+	}
+	return length, nil
+}
+
+// parseBulk reads a bulk payload and its terminating CRLF.
+func (p *Parser) parseBulk(length int) ([]byte, error) {
+	bulk := make([]byte, length)
+	if err := p.readFull(bulk); err != nil {
+		return nil, err
+	}
+	if err := p.readCRLF(); err != nil {
 		return nil, err
 	}
+	return bulk, nil
+}
 
-	array := make([]Value, count)
-	for i := range count {
+// parseItems reads count values of an aggregate type.
+func (p *Parser) parseItems(count int) ([]Value, error) {
+	items := make([]Value, 0, min(count, maxPreallocatedItems))
+	for range count {
 		value, err := p.Parse()
 		if err != nil {
 			return nil, err
 		}
-		array[i] = value
+		items = append(items, value)
+	}
+	return items, nil
+}
+
+// parseEntries reads count key and value pairs of a map or attribute.
+func (p *Parser) parseEntries(line string) ([]MapEntry, error) {
+	count, err := parseLength(line, maxAggregateLength, false)
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := p.parseItems(2 * count)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]MapEntry, count)
+	for i := range entries {
+		entries[i] = MapEntry{Key: items[2*i], Value: items[2*i+1]}
+	}
+	return entries, nil
+}
+
+func (p *Parser) parseArray(line string) (Value, error) {
+	count, err := parseLength(line, maxAggregateLength, true)
+	if err != nil {
+		return nil, err
+	}
+	if count == -1 {
+		return NewNullArray(), nil
 	}
 
-	return NewArray(array), nil
+	items, err := p.parseItems(count)
+	if err != nil {
+		return nil, err
+	}
+	return NewArray(items), nil
 }
 
 func (p *Parser) parseSimpleString(line string) (Value, error) {
 	return NewSimpleString(line[1:]), nil
 }
 
+func (p *Parser) parseInteger(line string) (Value, error) {
+	if _, err := strconv.ParseInt(line[1:], 10, 64); err != nil {
+		return nil, fmt.Errorf("%w: invalid integer %q", ErrProtocol, line[1:])
+	}
+	return NewInteger(line[1:]), nil
+}
+
 func (p *Parser) parseBulkString(line string) (Value, error) {
-	length, err := strconv.Atoi(line[1:])
+	length, err := parseLength(line, maxBulkLength, true)
 	if err != nil {
 		return nil, err
 	}
+	if length == -1 {
+		return NewNullBulkString(), nil
+	}
 
-	bulk := make([]byte, length)
-	_, err = io.ReadFull(p.reader, bulk)
+	bulk, err := p.parseBulk(length)
+	if err != nil {
+		return nil, err
+	}
+	return NewBulkBytes(bulk), nil
+}
+
+func (p *Parser) parseNull(line string) (Value, error) {
+	if len(line) != 1 {
+		return nil, fmt.Errorf("%w: invalid null %q", ErrProtocol, line)
+	}
+	return NewNull(), nil
+}
+
+func (p *Parser) parseDouble(line string) (Value, error) {
+	switch line[1:] {
+	case "inf":
+		return NewDouble(math.Inf(1)), nil
+	case "-inf":
+		return NewDouble(math.Inf(-1)), nil
+	case "nan":
+		return NewDouble(math.NaN()), nil
+	}
+
+	value, err := strconv.ParseFloat(line[1:], 64)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid double %q", ErrProtocol, line[1:])
+	}
+	return NewDouble(value), nil
+}
+
+func (p *Parser) parseBoolean(line string) (Value, error) {
+	switch line[1:] {
+	case "t":
+		return NewBoolean(true), nil
+	case "f":
+		return NewBoolean(false), nil
+	default:
+		return nil, fmt.Errorf("%w: invalid boolean %q", ErrProtocol, line[1:])
+	}
+}
+
+func (p *Parser) parseBigNumber(line string) (Value, error) {
+	if _, ok := new(big.Int).SetString(line[1:], 10); !ok {
+		return nil, fmt.Errorf("%w: invalid big number %q", ErrProtocol, line[1:])
+	}
+	return NewBigNumber(line[1:]), nil
+}
+
+func (p *Parser) parseBlobError(line string) (Value, error) {
+	length, err := parseLength(line, maxBulkLength, false)
 	if err != nil {
 		return nil, err
 	}
 
-	p.readLine()
+	bulk, err := p.parseBulk(length)
+	if err != nil {
+		return nil, err
+	}
+	return NewBlobError(string(bulk)), nil
+}
+
+func (p *Parser) parseVerbatim(line string) (Value, error) {
+	length, err := parseLength(line, maxBulkLength, false)
+	if err != nil {
+		return nil, err
+	}
 
-	return NewBulkString(string(bulk)), nil
+	bulk, err := p.parseBulk(length)
+	if err != nil {
+		return nil, err
+	}
+
+	// The text is prefixed by a three letter format and a colon
+	if len(bulk) < 4 || bulk[3] != ':' {
+		return nil, fmt.Errorf("%w: invalid verbatim string %q", ErrProtocol, bulk)
+	}
+	return NewVerbatim(string(bulk[:3]), string(bulk[4:])), nil
+}
+
+func (p *Parser) parseMap(line string) (Value, error) {
+	entries, err := p.parseEntries(line)
+	if err != nil {
+		return nil, err
+	}
+	return NewMap(entries), nil
+}
+
+func (p *Parser) parseSet(line string) (Value, error) {
+	count, err := parseLength(line, maxAggregateLength, false)
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := p.parseItems(count)
+	if err != nil {
+		return nil, err
+	}
+	return NewSet(items), nil
+}
+
+func (p *Parser) parsePush(line string) (Value, error) {
+	count, err := parseLength(line, maxAggregateLength, false)
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := p.parseItems(count)
+	if err != nil {
+		return nil, err
+	}
+	return NewPush(items), nil
+}
+
+// parseAttribute reads the attributes and the value they describe.
+func (p *Parser) parseAttribute(line string) (Value, error) {
+	entries, err := p.parseEntries(line)
+	if err != nil {
+		return nil, err
+	}
+
+	value, err := p.Parse()
+	if err != nil {
+		return nil, err
+	}
+	return NewAttribute(entries, value), nil
 }
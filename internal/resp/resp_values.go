@@ -9,33 +9,34 @@ type Value interface {
 
 type Array struct {
 	items []Value
+	null  bool
 }
 
 func NewArray(items []Value) *Array {
-	return &Array{items}
+	return &Array{items: items}
+}
+
+// NewNullArray returns the RESP2 null array, which some commands reply with
+// instead of the null bulk string.
+func NewNullArray() *Array {
+	return &Array{null: true}
+}
+
+func (a *Array) IsNull() bool {
+	return a.null
 }
 
 func (a *Array) Items() []Value {
 	return a.items
 }
 
-func (a *Array) String() string {
-	result := ""
-	for i, item := range a.items {
-		if i > 0 {
-			result += " "
-		}
-		result += item.String()
-	}
-	return result
-}
+func (a *Array) String() string { return joinStrings(a.items) }
 
 func (a *Array) Serialize() []byte {
-	result := fmt.Appendf(nil, "*%d%s", len(a.items), CRLF)
-	for _, item := range a.items {
-		result = append(result, item.Serialize()...)
+	if a.null {
+		return []byte("*-1" + CRLF)
 	}
-	return result
+	return serializeAggregate('*', len(a.items), a.items)
 }
 
 type SimpleString struct {
@@ -70,27 +71,51 @@ func (s *SimpleError) Serialize() []byte {
 	return fmt.Appendf(nil, "-%s%s", s.value, CRLF)
 }
 
+// BulkString is a binary safe string. The null bulk string is distinct from
+// every payload, including the empty one.
 type BulkString struct {
-	value string
+	value []byte
+	null  bool
 }
 
 func NewBulkString(value string) *BulkString {
-	return &BulkString{value}
+	return &BulkString{value: []byte(value)}
+}
+
+// NewBulkBytes returns a bulk string holding value without copying it.
+func NewBulkBytes(value []byte) *BulkString {
+	return &BulkString{value: value}
+}
+
+// Bytes returns the payload of a bulk string, or the text of any other
+// value, so commands can store arguments without converting them.
+func Bytes(value Value) []byte {
+	if bulk, ok := value.(*BulkString); ok {
+		return bulk.Bytes()
+	}
+	return []byte(value.String())
 }
 
 func NewNullBulkString() *BulkString {
-	return &BulkString{"null"}
+	return &BulkString{null: true}
 }
 
 func (s *BulkString) Type() string { return "BulkString " }
 
-func (s *BulkString) String() string { return s.value }
+func (s *BulkString) IsNull() bool { return s.null }
+
+// Bytes returns the payload of the bulk string, which is nil for null.
+func (s *BulkString) Bytes() []byte { return s.value }
+
+func (s *BulkString) String() string { return string(s.value) }
 
 func (s *BulkString) Serialize() []byte {
-	if s.value == "null" {
+	if s.null {
 		return []byte("$-1\r\n")
 	}
-	return fmt.Appendf(nil, "$%d%s%s%s", len(s.value), CRLF, s.value, CRLF)
+	result := fmt.Appendf(nil, "$%d%s", len(s.value), CRLF)
+	result = append(result, s.value...)
+	return append(result, CRLF...)
 }
 
 type Integer struct {
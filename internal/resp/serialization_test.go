@@ -1,6 +1,9 @@
 package resp
 
-import "testing"
+import (
+	"math"
+	"testing"
+)
 
 func TestSerializeSimpleString(t *testing.T) {
 	value := NewSimpleString("PONG")
@@ -31,3 +34,61 @@ func TestSerializeSimpleError(t *testing.T) {
 		t.Errorf("Expected %q, got %q", expected, actual)
 	}
 }
+
+func TestSerializeRESP3Types(t *testing.T) {
+	tests := []struct {
+		value    Value
+		expected string
+	}{
+		{NewNull(), "_\r\n"},
+		{NewDouble(1.5), ",1.5\r\n"},
+		{NewDouble(math.Inf(-1)), ",-inf\r\n"},
+		{NewBoolean(true), "#t\r\n"},
+		{NewBoolean(false), "#f\r\n"},
+		{NewBigNumber("3492890328409238509324850943850943825024385"), "(3492890328409238509324850943850943825024385\r\n"},
+		{NewVerbatim("txt", "Some string"), "=15\r\ntxt:Some string\r\n"},
+		{NewMap([]MapEntry{{NewSimpleString("first"), NewInteger("1")}}), "%1\r\n+first\r\n:1\r\n"},
+		{NewSet([]Value{NewBulkString("a"), NewInteger("2")}), "~2\r\n$1\r\na\r\n:2\r\n"},
+		{NewPush([]Value{NewBulkString("message")}), ">1\r\n$7\r\nmessage\r\n"},
+		{NewAttribute([]MapEntry{{NewSimpleString("ttl"), NewInteger("3600")}}, NewInteger("1")), "|1\r\n+ttl\r\n:3600\r\n:1\r\n"},
+	}
+
+	for _, test := range tests {
+		if actual := string(test.value.Serialize()); actual != test.expected {
+			t.Errorf("Expected %q, got %q", test.expected, actual)
+		}
+	}
+}
+
+func TestEncodeForRESP2(t *testing.T) {
+	reply := NewArray([]Value{
+		NewMap([]MapEntry{{NewBulkString("proto"), NewInteger("2")}}),
+		NewSet([]Value{NewBulkString("a")}),
+		NewNull(),
+		NewDouble(0.25),
+		NewBoolean(true),
+		NewVerbatim("txt", "hi"),
+		NewAttribute([]MapEntry{{NewBulkString("key"), NewBulkString("value")}}, NewBulkString("data")),
+	})
+
+	expected := "*7\r\n" +
+		"*2\r\n$5\r\nproto\r\n:2\r\n" +
+		"*1\r\n$1\r\na\r\n" +
+		"$-1\r\n" +
+		"$4\r\n0.25\r\n" +
+		":1\r\n" +
+		"$2\r\nhi\r\n" +
+		"$4\r\ndata\r\n"
+	if actual := string(Encode(reply, RESP2)); actual != expected {
+		t.Errorf("Expected %q, got %q", expected, actual)
+	}
+}
+
+func TestEncodeForRESP3(t *testing.T) {
+	reply := NewArray([]Value{NewBulkString("a"), NewNullBulkString()})
+
+	expected := "*2\r\n$1\r\na\r\n_\r\n"
+	if actual := string(Encode(reply, RESP3)); actual != expected {
+		t.Errorf("Expected %q, got %q", expected, actual)
+	}
+}
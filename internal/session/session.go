@@ -0,0 +1,46 @@
+package session
+
+import (
+	"net"
+
+	"github.com/md-talim/codecrafters-redis-go/internal/resp"
+)
+
+// Session holds the state of a single client connection.
+type Session struct {
+	ID   int
+	Conn net.Conn
+
+	// Protocol is the RESP version negotiated with HELLO.
+	Protocol int
+
+	// Name is the name the client set with HELLO SETNAME.
+	Name string
+
+	// DB is the index of the database selected with SELECT.
+	DB int
+
+	// ListeningPort is the port a replica announced through REPLCONF.
+	ListeningPort string
+
+	// Master reports whether this is the link to our own master, whose
+	// writes are applied even on a read-only replica.
+	Master bool
+}
+
+func New(id int, conn net.Conn) *Session {
+	return &Session{
+		ID:       id,
+		Conn:     conn,
+		Protocol: resp.RESP2,
+	}
+}
+
+// Detached returns a session that is not backed by a network connection.
+func Detached() *Session {
+	return &Session{Protocol: resp.RESP2}
+}
+
+func (s *Session) IsDetached() bool {
+	return s.Conn == nil
+}
@@ -0,0 +1,132 @@
+package stats
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Stats holds the server wide counters reported by INFO.
+type Stats struct {
+	startTime time.Time
+
+	connectedClients atomic.Int64
+	totalConnections atomic.Int64
+	totalCommands    atomic.Int64
+
+	rdbLoad RDBLoad
+	mu      sync.RWMutex
+
+	loading         atomic.Bool
+	loadingProgress Loading
+}
+
+// Loading describes the progress of loading the dataset at startup.
+type Loading struct {
+	StartTime   time.Time
+	TotalBytes  int64
+	LoadedBytes int64
+}
+
+// Percent returns how much of the dataset was loaded, from 0 to 100.
+func (l Loading) Percent() float64 {
+	if l.TotalBytes <= 0 {
+		return 0
+	}
+	return float64(l.LoadedBytes) * 100 / float64(l.TotalBytes)
+}
+
+// ETA estimates the time left from the rate at which bytes were loaded so
+// far.
+func (l Loading) ETA(now time.Time) time.Duration {
+	if l.LoadedBytes <= 0 || l.TotalBytes <= l.LoadedBytes {
+		return 0
+	}
+	elapsed := now.Sub(l.StartTime)
+	return time.Duration(float64(elapsed) * float64(l.TotalBytes-l.LoadedBytes) / float64(l.LoadedBytes))
+}
+
+// RDBLoad describes the outcome of loading the RDB file at startup.
+type RDBLoad struct {
+	KeysLoaded  int
+	KeysExpired int
+	Err         error
+}
+
+func New() *Stats {
+	return &Stats{startTime: time.Now()}
+}
+
+func (s *Stats) Uptime() time.Duration {
+	return time.Since(s.startTime)
+}
+
+func (s *Stats) ClientConnected() {
+	s.connectedClients.Add(1)
+	s.totalConnections.Add(1)
+}
+
+func (s *Stats) ClientDisconnected() {
+	s.connectedClients.Add(-1)
+}
+
+func (s *Stats) CommandProcessed() {
+	s.totalCommands.Add(1)
+}
+
+func (s *Stats) ConnectedClients() int64 {
+	return s.connectedClients.Load()
+}
+
+func (s *Stats) TotalConnections() int64 {
+	return s.totalConnections.Load()
+}
+
+func (s *Stats) TotalCommands() int64 {
+	return s.totalCommands.Load()
+}
+
+func (s *Stats) SetRDBLoad(load RDBLoad) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rdbLoad = load
+}
+
+func (s *Stats) RDBLoad() RDBLoad {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.rdbLoad
+}
+
+// StartLoading marks the dataset as being loaded.
+func (s *Stats) StartLoading() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.loadingProgress = Loading{StartTime: time.Now()}
+	s.loading.Store(true)
+}
+
+// LoadingProgress records that loaded of total bytes were loaded.
+func (s *Stats) LoadingProgress(loaded, total int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.loadingProgress.LoadedBytes = loaded
+	s.loadingProgress.TotalBytes = total
+}
+
+// FinishLoading marks the dataset as loaded.
+func (s *Stats) FinishLoading() {
+	s.loading.Store(false)
+}
+
+// IsLoading reports whether the dataset is still being loaded.
+func (s *Stats) IsLoading() bool {
+	return s.loading.Load()
+}
+
+// Loading returns the progress of the current load.
+func (s *Stats) Loading() Loading {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.loadingProgress
+}
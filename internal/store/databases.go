@@ -0,0 +1,124 @@
+package store
+
+import "time"
+
+// Databases holds the numbered keyspaces a client chooses between with
+// SELECT. Each database keeps its identity for the lifetime of the server,
+// so commands bound to one see the data swapped into it by SWAPDB.
+type Databases struct {
+	dbs []*InMemory
+}
+
+// NewDatabases creates count empty databases, at least one.
+func NewDatabases(count int) *Databases {
+	dbs := make([]*InMemory, max(count, 1))
+	for i := range dbs {
+		dbs[i] = NewInMemory()
+	}
+	return &Databases{dbs: dbs}
+}
+
+// Len returns the number of databases.
+func (d *Databases) Len() int {
+	return len(d.dbs)
+}
+
+// DB returns the database with the given index.
+func (d *Databases) DB(index int) Storage {
+	return d.dbs[index]
+}
+
+// Valid reports whether index names an existing database.
+func (d *Databases) Valid(index int) bool {
+	return index >= 0 && index < len(d.dbs)
+}
+
+// Swap exchanges the contents of two databases.
+func (d *Databases) Swap(a, b int) {
+	if a == b {
+		return
+	}
+
+	first, second := d.lockPair(a, b)
+	defer d.unlockPair(first, second)
+
+	first.data, second.data = second.data, first.data
+	first.dirty.Add(1)
+}
+
+// Move transfers key, with its expiry, from the src database to dst. It
+// reports false when the key does not exist in src or already exists in dst.
+func (d *Databases) Move(key string, src, dst int) bool {
+	first, second := d.lockPair(src, dst)
+	defer d.unlockPair(first, second)
+
+	source, target := d.dbs[src], d.dbs[dst]
+	now := time.Now()
+
+	item, exists := source.data[key]
+	if !exists || (item.ExpriesAt != nil && now.After(*item.ExpriesAt)) {
+		return false
+	}
+	if existing, exists := target.data[key]; exists && (existing.ExpriesAt == nil || !now.After(*existing.ExpriesAt)) {
+		return false
+	}
+
+	delete(source.data, key)
+	target.data[key] = item
+	source.dirty.Add(1)
+	return true
+}
+
+// Flush removes every key from every database.
+func (d *Databases) Flush() {
+	for _, db := range d.dbs {
+		db.Flush()
+	}
+}
+
+// Snapshot returns a snapshot of each database, indexed by database number.
+func (d *Databases) Snapshot() []map[string]Item {
+	snapshots := make([]map[string]Item, len(d.dbs))
+	for i, db := range d.dbs {
+		snapshots[i] = db.Snapshot()
+	}
+	return snapshots
+}
+
+// Dirty returns the number of changes made to every database since they were
+// created.
+func (d *Databases) Dirty() int64 {
+	var dirty int64
+	for _, db := range d.dbs {
+		dirty += db.Dirty()
+	}
+	return dirty
+}
+
+func (d *Databases) Close() {
+	for _, db := range d.dbs {
+		db.Close()
+	}
+}
+
+// lockPair locks two databases in index order so concurrent pairs cannot
+// deadlock.
+func (d *Databases) lockPair(a, b int) (*InMemory, *InMemory) {
+	if a > b {
+		a, b = b, a
+	}
+
+	first, second := d.dbs[a], d.dbs[b]
+	first.mu.Lock()
+	if second != first {
+		second.mu.Lock()
+	}
+	return first, second
+}
+
+func (d *Databases) unlockPair(first, second *InMemory) {
+	if second != first {
+		second.mu.Unlock()
+	}
+	first.mu.Unlock()
+}
@@ -0,0 +1,88 @@
+package store
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/md-talim/codecrafters-redis-go/internal/rdb"
+)
+
+func TestDatabasesMove(t *testing.T) {
+	databases := NewDatabases(2)
+	defer databases.Close()
+
+	databases.DB(0).SetWithExpiry("foo", []byte("bar"), time.Hour)
+	databases.DB(0).Set("taken", []byte("zero"))
+	databases.DB(1).Set("taken", []byte("one"))
+
+	if !databases.Move("foo", 0, 1) {
+		t.Fatal("Expected foo to be moved")
+	}
+	if _, exists := databases.DB(0).Get("foo"); exists {
+		t.Error("Expected foo to be removed from database 0")
+	}
+	if _, expires := databases.DB(1).Count(); expires != 1 {
+		t.Error("Expected the expiry to move with the key")
+	}
+
+	if databases.Move("taken", 0, 1) {
+		t.Error("Expected no move when the key exists in the destination")
+	}
+	if databases.Move("missing", 0, 1) {
+		t.Error("Expected no move for a missing key")
+	}
+}
+
+func TestDatabasesSwap(t *testing.T) {
+	databases := NewDatabases(2)
+	defer databases.Close()
+
+	first := databases.DB(0)
+	first.Set("foo", []byte("zero"))
+	databases.DB(1).Set("bar", []byte("one"))
+
+	databases.Swap(0, 1)
+
+	// Storage handed out before the swap now sees the other database's data
+	if value, exists := first.Get("bar"); !exists || !reflect.DeepEqual(value, []byte("one")) {
+		t.Errorf("Expected bar=one in database 0, got %v", value)
+	}
+	if value, exists := databases.DB(1).Get("foo"); !exists || !reflect.DeepEqual(value, []byte("zero")) {
+		t.Errorf("Expected foo=zero in database 1, got %v", value)
+	}
+}
+
+func TestWriteRDBPreservesDatabaseNumbers(t *testing.T) {
+	databases := NewDatabases(16)
+	defer databases.Close()
+
+	databases.DB(0).Set("foo", []byte("zero"))
+	databases.DB(7).Set("foo", []byte("seven"))
+
+	var buffer bytes.Buffer
+	if err := WriteRDB(databases, &buffer); err != nil {
+		t.Fatalf("WriteRDB failed: %v", err)
+	}
+
+	loaded := NewDatabases(16)
+	defer loaded.Close()
+	if _, err := LoadRDB(loaded, rdb.NewReaderFrom(bytes.NewReader(buffer.Bytes()))); err != nil {
+		t.Fatalf("LoadRDB failed: %v", err)
+	}
+
+	for index, expected := range map[int]string{0: "zero", 7: "seven"} {
+		if value, _ := loaded.DB(index).Get("foo"); !reflect.DeepEqual(value, []byte(expected)) {
+			t.Errorf("Expected foo=%s in database %d, got %v", expected, index, value)
+		}
+	}
+
+	tooFew := NewDatabases(4)
+	defer tooFew.Close()
+	_, err := LoadRDB(tooFew, rdb.NewReaderFrom(bytes.NewReader(buffer.Bytes())))
+	if err == nil || !strings.Contains(err.Error(), "database 7") {
+		t.Errorf("Expected an error naming database 7, got %v", err)
+	}
+}
@@ -0,0 +1,49 @@
+package store
+
+import (
+	"maps"
+	"sync"
+)
+
+type Hash struct {
+	fields map[string]string
+	mu     sync.RWMutex
+}
+
+func NewHash() *Hash {
+	return &Hash{fields: make(map[string]string)}
+}
+
+// Set stores the field and reports whether it is new.
+func (h *Hash) Set(field, value string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, exists := h.fields[field]
+	h.fields[field] = value
+	return !exists
+}
+
+func (h *Hash) Get(field string) (string, bool) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	value, exists := h.fields[field]
+	return value, exists
+}
+
+// Fields returns a copy of every field and its value.
+func (h *Hash) Fields() map[string]string {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return maps.Clone(h.fields)
+}
+
+func (h *Hash) Size() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.fields)
+}
+
+// Clone returns a copy of the hash that is not affected by later changes.
+func (h *Hash) Clone() *Hash {
+	return &Hash{fields: h.Fields()}
+}
@@ -2,6 +2,7 @@ package store
 
 import (
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -14,6 +15,9 @@ type InMemory struct {
 	data   map[string]*Item
 	mu     sync.RWMutex
 	closer chan struct{}
+
+	// dirty counts the changes made since the storage was created
+	dirty atomic.Int64
 }
 
 func newItem(value any, expiresAt *time.Time) *Item {
@@ -35,6 +39,7 @@ func (m *InMemory) Set(key string, value any) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	m.data[key] = newItem(value, nil)
+	m.dirty.Add(1)
 	return nil
 }
 
@@ -44,6 +49,7 @@ func (m *InMemory) SetWithExpiry(key string, value any, expiry time.Duration) er
 
 	expiryTime := time.Now().Add(expiry)
 	m.data[key] = newItem(value, &expiryTime)
+	m.dirty.Add(1)
 	return nil
 }
 
@@ -72,7 +78,10 @@ func (m *InMemory) Get(key string) (any, bool) {
 func (m *InMemory) Delete(key string) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
-	delete(m.data, key)
+	if _, exists := m.data[key]; exists {
+		delete(m.data, key)
+		m.dirty.Add(1)
+	}
 	return nil
 }
 
@@ -93,6 +102,81 @@ func (m *InMemory) Keys() []string {
 	return keys
 }
 
+// Count returns the number of live keys and how many of them have an expiry.
+func (m *InMemory) Count() (int, int) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	keys, expires := 0, 0
+	now := time.Now()
+
+	for _, item := range m.data {
+		if item.ExpriesAt != nil {
+			if now.After(*item.ExpriesAt) {
+				continue
+			}
+			expires++
+		}
+		keys++
+	}
+
+	return keys, expires
+}
+
+// Flush removes every key from the storage.
+func (m *InMemory) Flush() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.dirty.Add(int64(len(m.data)))
+	m.data = make(map[string]*Item)
+}
+
+func (m *InMemory) MarkDirty(changes int) {
+	m.dirty.Add(int64(changes))
+}
+
+// Dirty returns the number of changes made since the storage was created.
+func (m *InMemory) Dirty() int64 {
+	return m.dirty.Load()
+}
+
+// Snapshot returns a copy of every live item in the storage. Mutable values
+// such as lists are cloned so the snapshot stays consistent.
+func (m *InMemory) Snapshot() map[string]Item {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	snapshot := make(map[string]Item, len(m.data))
+	now := time.Now()
+
+	for key, item := range m.data {
+		if item.ExpriesAt != nil && now.After(*item.ExpriesAt) {
+			continue
+		}
+		copied := *item
+		copied.Value = cloneValue(item.Value)
+		snapshot[key] = copied
+	}
+
+	return snapshot
+}
+
+// cloneValue copies the collection types, which are changed in place.
+func cloneValue(value any) any {
+	switch value := value.(type) {
+	case *List:
+		return value.Clone()
+	case *Set:
+		return value.Clone()
+	case *Hash:
+		return value.Clone()
+	case *SortedSet:
+		return value.Clone()
+	default:
+		return value
+	}
+}
+
 func (m *InMemory) Close() {
 	close(m.closer)
 }
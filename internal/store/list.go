@@ -1,9 +1,14 @@
 package store
 
-import "github.com/md-talim/codecrafters-redis-go/internal/resp"
+import (
+	"sync"
+
+	"github.com/md-talim/codecrafters-redis-go/internal/resp"
+)
 
 type List struct {
 	items []resp.Value
+	mu    sync.RWMutex
 }
 
 func NewList() *List {
@@ -11,30 +16,53 @@ func NewList() *List {
 }
 
 func (l *List) Append(newItems []resp.Value) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
 	l.items = append(l.items, newItems...)
 }
 
 func (l *List) Prepend(newItems []resp.Value) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
 	for _, item := range newItems {
 		l.items = append([]resp.Value{item}, l.items...)
 	}
 }
 
 func (l *List) Size() int {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
 	return len(l.items)
 }
 
 func (l *List) Range(start, stop int) []resp.Value {
-	return l.items[start:stop]
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	result := make([]resp.Value, stop-start)
+	copy(result, l.items[start:stop])
+	return result
 }
 
 // Pop removes the first element of the list and returns it
 func (l *List) Pop() resp.Value {
+	l.mu.Lock()
+	defer l.mu.Unlock()
 	firstElement := l.items[0]
 	l.items = l.items[1:]
 	return firstElement
 }
 
 func (l *List) IsEmpty() bool {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
 	return len(l.items) == 0
 }
+
+// Clone returns a copy of the list that is not affected by later changes.
+func (l *List) Clone() *List {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	items := make([]resp.Value, len(l.items))
+	copy(items, l.items)
+	return &List{items: items}
+}
@@ -0,0 +1,58 @@
+package store
+
+import "sync"
+
+type Set struct {
+	members map[string]struct{}
+	mu      sync.RWMutex
+}
+
+func NewSet() *Set {
+	return &Set{members: make(map[string]struct{})}
+}
+
+// Add inserts the members and returns how many were not already present.
+func (s *Set) Add(members ...string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	added := 0
+	for _, member := range members {
+		if _, exists := s.members[member]; !exists {
+			s.members[member] = struct{}{}
+			added++
+		}
+	}
+	return added
+}
+
+func (s *Set) Contains(member string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	_, exists := s.members[member]
+	return exists
+}
+
+func (s *Set) Members() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	members := make([]string, 0, len(s.members))
+	for member := range s.members {
+		members = append(members, member)
+	}
+	return members
+}
+
+func (s *Set) Size() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.members)
+}
+
+// Clone returns a copy of the set that is not affected by later changes.
+func (s *Set) Clone() *Set {
+	clone := NewSet()
+	clone.Add(s.Members()...)
+	return clone
+}
@@ -0,0 +1,72 @@
+package store
+
+import (
+	"cmp"
+	"slices"
+	"sync"
+)
+
+type SortedSetEntry struct {
+	Member string
+	Score  float64
+}
+
+type SortedSet struct {
+	scores map[string]float64
+	mu     sync.RWMutex
+}
+
+func NewSortedSet() *SortedSet {
+	return &SortedSet{scores: make(map[string]float64)}
+}
+
+// Add sets the score of the member and reports whether it is new.
+func (z *SortedSet) Add(member string, score float64) bool {
+	z.mu.Lock()
+	defer z.mu.Unlock()
+	_, exists := z.scores[member]
+	z.scores[member] = score
+	return !exists
+}
+
+func (z *SortedSet) Score(member string) (float64, bool) {
+	z.mu.RLock()
+	defer z.mu.RUnlock()
+	score, exists := z.scores[member]
+	return score, exists
+}
+
+// Entries returns the members ordered by score, then lexicographically.
+func (z *SortedSet) Entries() []SortedSetEntry {
+	z.mu.RLock()
+	defer z.mu.RUnlock()
+
+	entries := make([]SortedSetEntry, 0, len(z.scores))
+	for member, score := range z.scores {
+		entries = append(entries, SortedSetEntry{member, score})
+	}
+
+	slices.SortFunc(entries, func(a, b SortedSetEntry) int {
+		if c := cmp.Compare(a.Score, b.Score); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Member, b.Member)
+	})
+	return entries
+}
+
+func (z *SortedSet) Size() int {
+	z.mu.RLock()
+	defer z.mu.RUnlock()
+	return len(z.scores)
+}
+
+// Clone returns a copy of the sorted set that is not affected by later
+// changes.
+func (z *SortedSet) Clone() *SortedSet {
+	clone := NewSortedSet()
+	for _, entry := range z.Entries() {
+		clone.Add(entry.Member, entry.Score)
+	}
+	return clone
+}
@@ -1,10 +1,16 @@
 package store
 
 import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
 	"time"
 
 	"github.com/md-talim/codecrafters-redis-go/internal/config"
 	"github.com/md-talim/codecrafters-redis-go/internal/rdb"
+	"github.com/md-talim/codecrafters-redis-go/internal/resp"
 )
 
 type Storage interface {
@@ -13,44 +19,253 @@ type Storage interface {
 	Get(key string) (any, bool)
 	Delete(key string) error
 	Keys() []string
+	Snapshot() map[string]Item
+	Flush()
+	Count() (keys int, expires int)
+
+	// MarkDirty records changes made to a value in place, such as popping
+	// from a list, which the storage cannot see
+	MarkDirty(changes int)
+}
+
+// LoadResult summarizes the keys read from an RDB file.
+type LoadResult struct {
+	KeysLoaded  int
+	KeysExpired int
 }
 
-func New(cfg *config.Config) Storage {
-	storage := NewInMemory()
-	if cfg.Dir != "" && cfg.DBFilename != "" {
-		loadRDBData(storage, cfg.Dir, cfg.DBFilename)
+// Load reads the RDB file named by the config into the databases. A missing
+// file is not an error. If progress is not nil, it is called with the bytes
+// loaded so far and the size of the file as loading advances.
+func Load(databases *Databases, cfg *config.Config, progress func(loaded, total int64)) (LoadResult, error) {
+	if cfg.Dir == "" || cfg.DBFilename == "" {
+		return LoadResult{}, nil
 	}
-	return storage
+	return loadRDBData(databases, cfg, progress)
 }
 
-func loadRDBData(storage *InMemory, dir, filename string) error {
-	reader, err := rdb.NewReader(dir, filename)
+func loadRDBData(databases *Databases, cfg *config.Config, progress func(loaded, total int64)) (LoadResult, error) {
+	reader, err := rdb.NewReader(cfg.Dir, cfg.DBFilename)
 	if err != nil {
-		return err
+		return LoadResult{}, err
 	}
 	if reader == nil {
-		return nil
+		return LoadResult{}, nil
 	}
 	defer reader.Close()
+	reader.SkipZeroChecksum(cfg.RDBSkipZeroChecksum)
+
+	if progress != nil {
+		var total int64
+		if info, err := os.Stat(filepath.Join(cfg.Dir, cfg.DBFilename)); err == nil {
+			total = info.Size()
+		}
+		progress(0, total)
+		reader.OnProgress(func(offset int64) { progress(offset, total) })
+	}
+
+	return LoadRDB(databases, reader)
+}
+
+// LoadRDB adds every key read from the RDB reader to the database it was
+// saved in.
+func LoadRDB(databases *Databases, reader *rdb.Reader) (LoadResult, error) {
+	var result LoadResult
 
 	data, err := reader.ReadRDB()
 	if err != nil {
-		return err
+		return result, err
+	}
+
+	for index := range data.Databases {
+		if !databases.Valid(index) {
+			return result, fmt.Errorf("RDB file contains database %d, but only %d databases are configured", index, databases.Len())
+		}
 	}
 
 	now := time.Now()
-	for key, value := range data.Keys {
-		// Skip expired keys
-		if value.ExpiresAt != nil && now.After(*value.ExpiresAt) {
+	for index, keys := range data.Databases {
+		storage := databases.DB(index)
+		for key, value := range keys {
+			// Skip expired keys
+			if value.ExpiresAt != nil && now.After(*value.ExpiresAt) {
+				result.KeysExpired++
+				continue
+			}
+			result.KeysLoaded++
+
+			item := fromRDBValue(value.Value)
+			if value.ExpiresAt != nil {
+				storage.SetWithExpiry(key, item, value.ExpiresAt.Sub(now))
+			} else {
+				storage.Set(key, item)
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// fromRDBValue converts a decoded RDB value to its storage type.
+func fromRDBValue(value any) any {
+	switch value := value.(type) {
+	case rdb.List:
+		list := NewList()
+		items := make([]resp.Value, len(value))
+		for i, item := range value {
+			items[i] = resp.NewBulkString(item)
+		}
+		list.Append(items)
+		return list
+	case rdb.Set:
+		set := NewSet()
+		set.Add(value...)
+		return set
+	case rdb.Hash:
+		hash := NewHash()
+		for field, fieldValue := range value {
+			hash.Set(field, fieldValue)
+		}
+		return hash
+	case rdb.SortedSet:
+		sortedSet := NewSortedSet()
+		for _, entry := range value {
+			sortedSet.Add(entry.Member, entry.Score)
+		}
+		return sortedSet
+	case string:
+		return []byte(value)
+	default:
+		return value
+	}
+}
+
+// WriteRDB serializes the current contents of the databases as an RDB file.
+func WriteRDB(databases *Databases, w io.Writer) error {
+	return WriteSnapshot(databases.Snapshot(), w)
+}
+
+// WriteSnapshot serializes a snapshot taken with Databases.Snapshot as an
+// RDB file. Empty databases are left out.
+func WriteSnapshot(snapshot []map[string]Item, w io.Writer) error {
+	writer := rdb.NewWriter(w)
+	if err := writer.WriteHeader(); err != nil {
+		return err
+	}
+
+	aux := [][2]string{
+		{"redis-ver", "7.2.0"},
+		{"redis-bits", "64"},
+		{"ctime", strconv.FormatInt(time.Now().Unix(), 10)},
+		{"aof-base", "0"},
+	}
+	for _, field := range aux {
+		if err := writer.WriteAux(field[0], field[1]); err != nil {
+			return err
+		}
+	}
+
+	for index, items := range snapshot {
+		if len(items) == 0 {
 			continue
 		}
+		if err := writeDatabase(writer, index, items); err != nil {
+			return err
+		}
+	}
 
-		if value.ExpiresAt != nil {
-			storage.SetWithExpiry(key, value.Value, value.ExpiresAt.Sub(now))
-		} else {
-			storage.Set(key, value.Value)
+	return writer.WriteEOF()
+}
+
+func writeDatabase(writer *rdb.Writer, index int, items map[string]Item) error {
+	expires := 0
+	for _, item := range items {
+		if item.ExpriesAt != nil {
+			expires++
 		}
 	}
 
+	if err := writer.WriteSelectDB(index); err != nil {
+		return err
+	}
+	if err := writer.WriteResizeDB(len(items), expires); err != nil {
+		return err
+	}
+
+	for key, item := range items {
+		if err := writeItem(writer, key, item); err != nil {
+			return err
+		}
+	}
 	return nil
 }
+
+func writeItem(writer *rdb.Writer, key string, item Item) error {
+	value, err := toRDBValue(item.Value)
+	if err != nil {
+		return fmt.Errorf("key %q: %w", key, err)
+	}
+
+	switch value := value.(type) {
+	case string:
+		return writer.WriteString(key, value, item.ExpriesAt)
+	case rdb.List:
+		return writer.WriteList(key, value, item.ExpriesAt)
+	case rdb.Set:
+		return writer.WriteSet(key, value, item.ExpriesAt)
+	case rdb.Hash:
+		return writer.WriteHash(key, value, item.ExpriesAt)
+	case rdb.SortedSet:
+		return writer.WriteSortedSet(key, value, item.ExpriesAt)
+	default:
+		return fmt.Errorf("cannot serialize value of type %T for key %q", value, key)
+	}
+}
+
+// toRDBValue converts a storage value to its RDB type, the inverse of
+// fromRDBValue.
+func toRDBValue(value any) (any, error) {
+	switch value := value.(type) {
+	case []byte:
+		return string(value), nil
+	case *List:
+		items := value.Range(0, value.Size())
+		elements := make(rdb.List, len(items))
+		for i, element := range items {
+			elements[i] = element.String()
+		}
+		return elements, nil
+	case *Set:
+		return rdb.Set(value.Members()), nil
+	case *Hash:
+		return rdb.Hash(value.Fields()), nil
+	case *SortedSet:
+		entries := value.Entries()
+		rdbEntries := make(rdb.SortedSet, len(entries))
+		for i, entry := range entries {
+			rdbEntries[i] = rdb.SortedSetEntry{Member: entry.Member, Score: entry.Score}
+		}
+		return rdbEntries, nil
+	default:
+		return nil, fmt.Errorf("cannot serialize value of type %T", value)
+	}
+}
+
+// Dump serializes a value in the format of the DUMP command.
+func Dump(value any) ([]byte, error) {
+	converted, err := toRDBValue(value)
+	if err != nil {
+		return nil, err
+	}
+	return rdb.Dump(converted)
+}
+
+// Restore decodes a value serialized by Dump, returning
+// rdb.ErrBadDumpPayload if its version or checksum is wrong.
+func Restore(payload []byte) (any, error) {
+	value, err := rdb.ParseDump(payload)
+	if err != nil {
+		return nil, err
+	}
+	return fromRDBValue(value), nil
+}
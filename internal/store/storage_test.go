@@ -1,10 +1,14 @@
 package store
 
 import (
+	"os"
+	"path/filepath"
+	"reflect"
 	"testing"
 	"time"
 
 	"github.com/md-talim/codecrafters-redis-go/internal/config"
+	"github.com/md-talim/codecrafters-redis-go/internal/resp"
 )
 
 func TestNewInMemory(t *testing.T) {
@@ -12,18 +16,31 @@ func TestNewInMemory(t *testing.T) {
 	defer storage.Close()
 
 	// Test basic operations
-	err := storage.Set("key1", "value1")
+	err := storage.Set("key1", []byte("value1"))
 	if err != nil {
 		t.Errorf("Set failed: %v", err)
 	}
 
 	value, exists := storage.Get("key1")
-	if !exists || value != "value1" {
+	if !exists || !reflect.DeepEqual(value, []byte("value1")) {
 		t.Errorf("Get failed: expected value1, got %s", value)
 	}
 }
 
-func TestNewWithConfig(t *testing.T) {
+// loadDB loads the RDB file named by the config and returns its first
+// database.
+func loadDB(t *testing.T, cfg *config.Config) Storage {
+	t.Helper()
+
+	databases := NewDatabases(16)
+	t.Cleanup(databases.Close)
+	if _, err := Load(databases, cfg, nil); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	return databases.DB(0)
+}
+
+func TestLoadWithoutRDB(t *testing.T) {
 	// Test with empty config (no RDB)
 	cfg := &config.Config{
 		Dir:        "",
@@ -31,13 +48,13 @@ func TestNewWithConfig(t *testing.T) {
 		Port:       "6379",
 	}
 
-	storage := New(cfg)
+	storage := loadDB(t, cfg)
 
 	// Should work as normal memory storage
-	storage.Set("test", "value")
+	storage.Set("test", []byte("value"))
 	value, exists := storage.Get("test")
 
-	if !exists || value != "value" {
+	if !exists || !reflect.DeepEqual(value, []byte("value")) {
 		t.Errorf("Expected test=value, got %s", value)
 	}
 }
@@ -50,7 +67,7 @@ func TestRDBLoading(t *testing.T) {
 		Port:       "6379",
 	}
 
-	storage := New(cfg)
+	storage := loadDB(t, cfg)
 
 	// Should still work (empty storage)
 	keys := storage.Keys()
@@ -64,11 +81,11 @@ func TestExpiry(t *testing.T) {
 	defer storage.Close()
 
 	// Set key with very short expiry
-	storage.SetWithExpiry("temp", "value", 10*time.Millisecond)
+	storage.SetWithExpiry("temp", []byte("value"), 10*time.Millisecond)
 
 	// Should exist immediately
 	value, exists := storage.Get("temp")
-	if !exists || value != "value" {
+	if !exists || !reflect.DeepEqual(value, []byte("value")) {
 		t.Error("Key should exist immediately after setting")
 	}
 
@@ -81,3 +98,107 @@ func TestExpiry(t *testing.T) {
 		t.Error("Key should be expired")
 	}
 }
+
+func TestWriteRDBRoundTrip(t *testing.T) {
+	databases := NewDatabases(1)
+	defer databases.Close()
+
+	storage := databases.DB(0)
+	storage.Set("foo", []byte("bar"))
+	storage.SetWithExpiry("temp", []byte("value"), time.Hour)
+
+	dir := t.TempDir()
+	file, err := os.Create(filepath.Join(dir, "dump.rdb"))
+	if err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+	if err := WriteRDB(databases, file); err != nil {
+		t.Fatalf("WriteRDB failed: %v", err)
+	}
+	file.Close()
+
+	loaded := loadDB(t, &config.Config{Dir: dir, DBFilename: "dump.rdb"})
+
+	value, exists := loaded.Get("foo")
+	if !exists || !reflect.DeepEqual(value, []byte("bar")) {
+		t.Errorf("Expected foo=bar, got %v", value)
+	}
+
+	value, exists = loaded.Get("temp")
+	if !exists || !reflect.DeepEqual(value, []byte("value")) {
+		t.Errorf("Expected temp=value, got %v", value)
+	}
+}
+
+func TestWriteRDBCollections(t *testing.T) {
+	databases := NewDatabases(1)
+	defer databases.Close()
+
+	storage := databases.DB(0)
+	list := NewList()
+	list.Append([]resp.Value{resp.NewBulkString("a"), resp.NewBulkString("b")})
+	storage.Set("list", list)
+
+	set := NewSet()
+	set.Add("x", "y")
+	storage.Set("set", set)
+
+	hash := NewHash()
+	hash.Set("field", "value")
+	storage.Set("hash", hash)
+
+	sortedSet := NewSortedSet()
+	sortedSet.Add("one", 1)
+	sortedSet.Add("half", 0.5)
+	storage.Set("zset", sortedSet)
+
+	dir := t.TempDir()
+	file, err := os.Create(filepath.Join(dir, "dump.rdb"))
+	if err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+	if err := WriteRDB(databases, file); err != nil {
+		t.Fatalf("WriteRDB failed: %v", err)
+	}
+	file.Close()
+
+	loadedDatabases := NewDatabases(1)
+	defer loadedDatabases.Close()
+	var total int64
+	result, err := Load(loadedDatabases, &config.Config{Dir: dir, DBFilename: "dump.rdb"}, func(_, size int64) { total = size })
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if info, _ := os.Stat(filepath.Join(dir, "dump.rdb")); total != info.Size() {
+		t.Errorf("Expected progress against %d bytes, got %d", info.Size(), total)
+	}
+	if result.KeysLoaded != 4 {
+		t.Errorf("Expected 4 keys loaded, got %d", result.KeysLoaded)
+	}
+
+	loaded := loadedDatabases.DB(0)
+	value, _ := loaded.Get("list")
+	if loadedList, ok := value.(*List); !ok || loadedList.Size() != 2 || loadedList.Range(0, 2)[1].String() != "b" {
+		t.Errorf("Expected list [a b], got %v", value)
+	}
+
+	value, _ = loaded.Get("set")
+	if loadedSet, ok := value.(*Set); !ok || loadedSet.Size() != 2 || !loadedSet.Contains("y") {
+		t.Errorf("Expected set {x y}, got %v", value)
+	}
+
+	value, _ = loaded.Get("hash")
+	if loadedHash, ok := value.(*Hash); !ok || loadedHash.Fields()["field"] != "value" {
+		t.Errorf("Expected hash {field: value}, got %v", value)
+	}
+
+	value, _ = loaded.Get("zset")
+	loadedSortedSet, ok := value.(*SortedSet)
+	if !ok {
+		t.Fatalf("Expected sorted set, got %T", value)
+	}
+	entries := loadedSortedSet.Entries()
+	if len(entries) != 2 || entries[0].Member != "half" || entries[1].Score != 1 {
+		t.Errorf("Expected [half:0.5 one:1], got %v", entries)
+	}
+}